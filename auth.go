@@ -0,0 +1,338 @@
+package apitest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 认证类型，见 AuthConfig.Type
+const (
+	AuthNone   = "none"
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+	AuthAPIKey = "api_key"
+	AuthOAuth2 = "oauth2"
+)
+
+// OAuth2 授权方式，见 AuthConfig.Grant
+const (
+	OAuth2ClientCredentials = "client_credentials"
+	OAuth2Password          = "password"
+)
+
+// tokenExpiryLeeway 令牌在过期前多久视为已过期，避免请求途中过期
+const tokenExpiryLeeway = 10 * time.Second
+
+// AuthConfig 认证配置，所有字符串字段都支持变量
+//
+// 套件的 auth 作用于所有用例和 setup/teardown 中的 api_call，用例的 auth 覆盖套件配置，
+// 写成 auth: none 时不认证。请求中已经设置的 Authorization 头或同名的 API Key 不会被覆盖
+type AuthConfig struct {
+	Type         string   `yaml:"type"`                    // none、basic、bearer、api_key 或 oauth2
+	Username     string   `yaml:"username,omitempty"`      // basic；oauth2 password 授权
+	Password     string   `yaml:"password,omitempty"`      // basic；oauth2 password 授权
+	Token        string   `yaml:"token,omitempty"`         // bearer
+	Name         string   `yaml:"name,omitempty"`          // api_key 的头或查询参数名，默认 X-API-Key
+	Value        string   `yaml:"value,omitempty"`         // api_key 的值
+	In           string   `yaml:"in,omitempty"`            // api_key 的位置：header（默认）或 query
+	Grant        string   `yaml:"grant,omitempty"`         // oauth2 授权方式：client_credentials（默认）或 password
+	TokenURL     string   `yaml:"token_url,omitempty"`     // oauth2 令牌地址，以 / 开头时相对于 base_url
+	ClientID     string   `yaml:"client_id,omitempty"`     // oauth2
+	ClientSecret string   `yaml:"client_secret,omitempty"` // oauth2
+	Scopes       []string `yaml:"scopes,omitempty"`        // oauth2
+}
+
+// UnmarshalYAML 支持 auth: none 的简写
+func (a *AuthConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		a.Type = node.Value
+		return nil
+	}
+	type plain AuthConfig
+	return node.Decode((*plain)(a))
+}
+
+// MarshalYAML 将 none 输出为 auth: none
+func (a AuthConfig) MarshalYAML() (any, error) {
+	if a.Type == AuthNone {
+		return AuthNone, nil
+	}
+	type plain AuthConfig
+	return plain(a), nil
+}
+
+// validate 检查认证配置
+func (a *AuthConfig) validate() error {
+	if a == nil {
+		return nil
+	}
+	switch a.Type {
+	case AuthNone, AuthBasic, AuthBearer:
+	case AuthAPIKey:
+		if a.In != "" && a.In != "header" && a.In != "query" {
+			return fmt.Errorf("invalid auth: api_key 'in' must be header or query, got '%s'", a.In)
+		}
+	case AuthOAuth2:
+		if a.TokenURL == "" {
+			return fmt.Errorf("invalid auth: oauth2 requires token_url")
+		}
+		if a.Grant != "" && a.Grant != OAuth2ClientCredentials && a.Grant != OAuth2Password {
+			return fmt.Errorf("invalid auth: oauth2 grant must be client_credentials or password, got '%s'", a.Grant)
+		}
+	default:
+		return fmt.Errorf("invalid auth type '%s': expected none, basic, bearer, api_key or oauth2", a.Type)
+	}
+	return nil
+}
+
+// apiKeyName api_key 的头或查询参数名
+func (a *AuthConfig) apiKeyName() string {
+	if a.Name == "" {
+		return "X-API-Key"
+	}
+	return a.Name
+}
+
+// validateAuth 检查套件和所有用例的认证配置，并将 api_key 头和查询参数加入脱敏列表
+func (r *TestRunner) validateAuth() error {
+	if err := r.suite.Suite.Auth.validate(); err != nil {
+		return err
+	}
+	configs := []*AuthConfig{r.suite.Suite.Auth}
+	for _, scenario := range r.suite.Scenarios {
+		for _, tc := range scenario.TestCases {
+			if err := tc.Auth.validate(); err != nil {
+				return fmt.Errorf("%s: %w", tc.Name, err)
+			}
+			configs = append(configs, tc.Auth)
+		}
+	}
+	for _, a := range configs {
+		if a == nil || a.Type != AuthAPIKey {
+			continue
+		}
+		if a.In == "query" {
+			r.redactQuery = append(r.redactQuery, a.apiKeyName())
+		} else {
+			r.redact = append(r.redact, a.apiKeyName())
+		}
+	}
+	return nil
+}
+
+// authFor 返回用例使用的认证配置，nil 表示不认证
+func (r *TestRunner) authFor(tc TestCase) *AuthConfig {
+	auth := r.suite.Suite.Auth
+	if tc.Auth != nil {
+		auth = tc.Auth
+	}
+	if auth == nil || auth.Type == AuthNone {
+		return nil
+	}
+	return auth
+}
+
+// applyAuth 为请求添加认证信息
+func (r *TestRunner) applyAuth(ctx context.Context, req *http.Request, auth *AuthConfig) error {
+	if auth == nil || auth.Type == AuthNone {
+		return nil
+	}
+	if auth.Type == AuthAPIKey {
+		name, value := auth.apiKeyName(), r.replaceVariables(auth.Value)
+		if auth.In == "query" {
+			q := req.URL.Query()
+			if !q.Has(name) {
+				q.Set(name, value)
+				req.URL.RawQuery = q.Encode()
+			}
+		} else if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+		return nil
+	}
+
+	if req.Header.Get("Authorization") != "" {
+		return nil
+	}
+	switch auth.Type {
+	case AuthBasic:
+		req.SetBasicAuth(r.replaceVariables(auth.Username), r.replaceVariables(auth.Password))
+	case AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+r.replaceVariables(auth.Token))
+	case AuthOAuth2:
+		token, err := r.oauth2Token(ctx, auth)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// tokenCache 按 OAuth2 配置缓存的访问令牌，parallel 场景之间共享
+// 获取令牌时不持有锁，同一配置的并发请求等待正在进行的获取，不同配置互不阻塞
+type tokenCache struct {
+	mu       sync.Mutex
+	tokens   map[string]cachedToken
+	fetching map[string]*tokenFetch
+}
+
+// tokenFetch 正在进行的令牌获取，done 关闭后 token 和 err 可读
+type tokenFetch struct {
+	done  chan struct{}
+	token cachedToken
+	err   error
+}
+
+// cachedToken 缓存的令牌，expiry 为零表示不过期
+type cachedToken struct {
+	value  string
+	expiry time.Time
+}
+
+// newTokenCache 创建空的令牌缓存
+func newTokenCache() *tokenCache {
+	return &tokenCache{tokens: make(map[string]cachedToken), fetching: make(map[string]*tokenFetch)}
+}
+
+// oauth2Request 替换变量后的 OAuth2 配置
+type oauth2Request struct {
+	grant, tokenURL, clientID, clientSecret, username, password, scope string
+}
+
+// resolveOAuth2 替换 OAuth2 配置中的变量
+func (r *TestRunner) resolveOAuth2(auth *AuthConfig) oauth2Request {
+	o := oauth2Request{
+		grant:        auth.Grant,
+		tokenURL:     r.replaceVariables(auth.TokenURL),
+		clientID:     r.replaceVariables(auth.ClientID),
+		clientSecret: r.replaceVariables(auth.ClientSecret),
+		username:     r.replaceVariables(auth.Username),
+		password:     r.replaceVariables(auth.Password),
+	}
+	if o.grant == "" {
+		o.grant = OAuth2ClientCredentials
+	}
+	if strings.HasPrefix(o.tokenURL, "/") {
+		o.tokenURL = r.suite.Suite.BaseURL + o.tokenURL
+	}
+	scopes := make([]string, len(auth.Scopes))
+	for i, s := range auth.Scopes {
+		scopes[i] = r.replaceVariables(s)
+	}
+	o.scope = strings.Join(scopes, " ")
+	return o
+}
+
+// key 缓存键
+func (o oauth2Request) key() string {
+	return strings.Join([]string{o.grant, o.tokenURL, o.clientID, o.username, o.scope}, "\x00")
+}
+
+// oauth2Token 返回缓存的令牌，不存在或即将过期时重新获取
+func (r *TestRunner) oauth2Token(ctx context.Context, auth *AuthConfig) (string, error) {
+	o := r.resolveOAuth2(auth)
+	key := o.key()
+	r.tokens.mu.Lock()
+	if t, ok := r.tokens.tokens[key]; ok && (t.expiry.IsZero() || time.Now().Add(tokenExpiryLeeway).Before(t.expiry)) {
+		r.tokens.mu.Unlock()
+		return t.value, nil
+	}
+	if f, ok := r.tokens.fetching[key]; ok {
+		r.tokens.mu.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		return f.token.value, f.err
+	}
+	f := &tokenFetch{done: make(chan struct{})}
+	r.tokens.fetching[key] = f
+	r.tokens.mu.Unlock()
+
+	f.token, f.err = r.fetchToken(ctx, o)
+
+	r.tokens.mu.Lock()
+	delete(r.tokens.fetching, key)
+	if f.err == nil {
+		r.tokens.tokens[key] = f.token
+	}
+	r.tokens.mu.Unlock()
+	close(f.done)
+	return f.token.value, f.err
+}
+
+// hasHeader 判断配置的请求头中是否有 name（不区分大小写）且取值非空
+func hasHeader(headers map[string]string, name string) bool {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) && v != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// invalidateToken 丢弃缓存的令牌，下次请求时重新获取
+func (r *TestRunner) invalidateToken(auth *AuthConfig) {
+	o := r.resolveOAuth2(auth)
+	r.tokens.mu.Lock()
+	defer r.tokens.mu.Unlock()
+	delete(r.tokens.tokens, o.key())
+}
+
+// fetchToken 向令牌地址请求访问令牌
+func (r *TestRunner) fetchToken(ctx context.Context, o oauth2Request) (cachedToken, error) {
+	form := url.Values{"grant_type": {o.grant}}
+	if o.scope != "" {
+		form.Set("scope", o.scope)
+	}
+	if o.grant == OAuth2Password {
+		form.Set("username", o.username)
+		form.Set("password", o.password)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("token request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if o.clientID != "" {
+		req.SetBasicAuth(url.QueryEscape(o.clientID), url.QueryEscape(o.clientSecret))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return cachedToken{}, fmt.Errorf("token request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return cachedToken{}, fmt.Errorf("token response has no access_token: %s", string(body))
+	}
+
+	t := cachedToken{value: token.AccessToken}
+	if token.ExpiresIn > 0 {
+		t.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	fmt.Fprintf(r.out, "    🔑 Fetched OAuth2 token from %s (%s grant)\n", o.tokenURL, o.grant)
+	return t, nil
+}
@@ -0,0 +1,276 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenServer 本地的 OAuth2 令牌服务和受保护的接口，可以吊销已发放的令牌
+type tokenServer struct {
+	mu     sync.Mutex
+	issued int
+	valid  map[string]bool
+	grants []string
+}
+
+func (s *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.URL.Path {
+	case "/oauth/token":
+		r.ParseForm()
+		id, secret, _ := r.BasicAuth()
+		grant := r.PostForm.Get("grant_type")
+		if id != "app" || secret != "s3cret" || grant == OAuth2Password && r.PostForm.Get("password") != "pw" {
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"error":"invalid_client"}`)
+			return
+		}
+		s.issued++
+		token := fmt.Sprintf("token-%d", s.issued)
+		s.valid[token] = true
+		s.grants = append(s.grants, grant+" "+r.PostForm.Get("scope")+" "+r.PostForm.Get("username"))
+		fmt.Fprintf(w, `{"access_token":%q,"token_type":"Bearer","expires_in":3600}`, token)
+	case "/revoke":
+		s.valid = make(map[string]bool)
+		w.WriteHeader(http.StatusNoContent)
+	case "/whoami":
+		// 返回服务端看到的认证信息
+		user, pass, basic := r.BasicAuth()
+		fmt.Fprintf(w, `{"authorization":%q,"basic":%q,"api_key":%q,"query_key":%q}`,
+			r.Header.Get("Authorization"), map[bool]string{true: user + ":" + pass}[basic], r.Header.Get("X-Key"), r.URL.Query().Get("api_key"))
+	default:
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !s.valid[token] {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"token":%q}`, token)
+	}
+}
+
+func TestTestRunnerAuth(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "auth.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "Auth"
+  auth:
+    type: oauth2
+    token_url: /oauth/token
+    client_id: app
+    client_secret: "{{client_secret}}"
+    scopes: [read, write]
+  setup:
+    - type: api_call
+      request: { method: GET, path: /setup }
+variables:
+  client_secret: s3cret
+  api_key: k-123
+scenarios:
+  - name: "Providers"
+    testcases:
+      - name: "Cached Token"
+        request: { method: GET, path: /orders }
+        expect:
+          status_code: 200
+          assertions:
+            - { path: "token", operator: "equals", value: "token-1" }
+      - name: "Revoke Tokens"
+        auth: none
+        request: { method: POST, path: /revoke }
+        expect: { status_code: 204 }
+      - name: "Refresh On 401"
+        request: { method: GET, path: /orders }
+        expect:
+          status_code: 200
+          assertions:
+            - { path: "token", operator: "equals", value: "token-2" }
+      - name: "Password Grant"
+        auth: { type: oauth2, grant: password, token_url: /oauth/token, client_id: app, client_secret: s3cret, username: alice, password: pw }
+        request: { method: GET, path: /orders }
+        expect:
+          assertions:
+            - { path: "token", operator: "equals", value: "token-3" }
+      - name: "No Auth"
+        auth: none
+        request: { method: GET, path: /whoami }
+        expect:
+          response_body: { authorization: "" }
+      - name: "Basic"
+        auth: { type: basic, username: alice, password: "{{client_secret}}" }
+        request: { method: GET, path: /whoami }
+        expect:
+          response_body: { basic: "alice:s3cret" }
+      - name: "Bearer"
+        auth: { type: bearer, token: "static-{{api_key}}" }
+        request: { method: GET, path: /whoami }
+        expect:
+          response_body: { authorization: "Bearer static-k-123" }
+      - name: "Explicit Header Wins"
+        auth: { type: bearer, token: "ignored" }
+        request: { method: GET, path: /whoami, headers: { Authorization: "Bearer mine" } }
+        expect:
+          response_body: { authorization: "Bearer mine" }
+      - name: "API Key Header"
+        auth: { type: api_key, name: X-Key, value: "{{api_key}}" }
+        request: { method: GET, path: /whoami }
+        expect:
+          response_body: { api_key: "k-123", authorization: "" }
+      - name: "API Key Query"
+        auth: { type: api_key, in: query, name: api_key, value: "{{api_key}}" }
+        request: { method: GET, path: /whoami, query: { page: "1" } }
+        expect:
+          response_body: { query_key: "k-123" }
+      - name: "Bad Client"
+        auth: { type: oauth2, token_url: /oauth/token, client_id: app, client_secret: wrong }
+        request: { method: GET, path: /orders }
+        expect: { status_code: 200 }
+      - name: "Lowercase Explicit Header"
+        request: { method: GET, path: /orders, headers: { authorization: "Bearer mine" } }
+        expect: { status_code: 401 }
+`), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("NewTestRunner failed: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	server := &tokenServer{valid: make(map[string]bool)}
+	runner.SetHandler(server)
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	results := runner.GetResults()
+	for _, result := range results[:10] {
+		if !result.Passed {
+			t.Errorf("Expected %s to pass, got %s", result.Name, result.Error)
+		}
+	}
+	if server.issued != 3 {
+		t.Errorf("Expected 3 tokens (setup and first case share one), got %d: %v", server.issued, server.grants)
+	}
+	if want := "client_credentials read write |client_credentials read write |password  alice"; strings.Join(server.grants, "|") != want {
+		t.Errorf("Unexpected token requests: %q", server.grants)
+	}
+	if attempts := results[2].Request.Attempts; attempts != 2 {
+		t.Errorf("Expected refresh to resend the request once, got %d attempts", attempts)
+	}
+
+	if results[10].Passed || !strings.Contains(results[10].Error, "authentication failed: token request failed with status 401") {
+		t.Errorf("Expected token error to fail the case, got %+v", results[10])
+	}
+	if !results[11].Passed || results[11].Request.Attempts != 1 {
+		t.Errorf("Expected an explicit lowercase authorization header not to trigger a refresh, got %+v", results[11])
+	}
+
+	exported := runner.exportedResults()
+	if got := exported[8].Request.Headers["X-Key"]; len(got) != 1 || got[0] != redactedValue {
+		t.Errorf("Expected custom api_key header to be redacted, got %v", got)
+	}
+}
+
+func TestAuthConfigYAML(t *testing.T) {
+	suite := NewSuite("Auth YAML").
+		Auth(AuthConfig{Type: AuthBearer, Token: "{{token}}"}).
+		Scenario(NewScenario("S").Case(NewCase("Public").Get("/health").NoAuth())).
+		Build()
+
+	var buf bytes.Buffer
+	if err := WriteSuite(&buf, suite); err != nil {
+		t.Fatalf("WriteSuite failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "auth: none") {
+		t.Errorf("Expected auth: none shorthand, got:\n%s", buf.String())
+	}
+	parsed, err := ParseSuite(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ParseSuite failed: %v", err)
+	}
+	if parsed.Suite.Auth.Type != AuthBearer || parsed.Scenarios[0].TestCases[0].Auth.Type != AuthNone {
+		t.Errorf("Unexpected round trip: %+v / %+v", parsed.Suite.Auth, parsed.Scenarios[0].TestCases[0].Auth)
+	}
+
+	invalid := []AuthConfig{
+		{Type: "digest"},
+		{Type: AuthOAuth2},
+		{Type: AuthOAuth2, TokenURL: "/token", Grant: "implicit"},
+		{Type: AuthAPIKey, In: "cookie"},
+	}
+	for _, auth := range invalid {
+		suite := NewSuite("Invalid").Scenario(NewScenario("S").Case(NewCase("C").Get("/").Auth(auth))).Build()
+		if _, err := NewTestRunnerFromSuite(suite, nil, nil); err == nil || !strings.Contains(err.Error(), "invalid auth") {
+			t.Errorf("Expected invalid auth error for %+v, got %v", auth, err)
+		}
+	}
+}
+
+func TestOAuth2TokenFetchDoesNotBlockOtherConfigs(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	fetches := map[string]int{}
+	runner, err := NewTestRunnerFromSuite(NewSuite("Tokens").Build(), nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("NewTestRunnerFromSuite failed: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	runner.SetHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches[r.URL.Path]++
+		mu.Unlock()
+		if r.URL.Path == "/slow/token" {
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token%s"}`, strings.ReplaceAll(r.URL.Path, "/", "-"))
+	}))
+	runner.SetBaseURL("http://tokens.test")
+
+	slow := &AuthConfig{Type: AuthOAuth2, TokenURL: "/slow/token", ClientID: "app"}
+	fast := &AuthConfig{Type: AuthOAuth2, TokenURL: "/fast/token", ClientID: "app"}
+
+	var wg sync.WaitGroup
+	slowTokens := make([]string, 2)
+	for i := range slowTokens {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slowTokens[i], _ = runner.oauth2Token(context.Background(), slow)
+		}()
+	}
+
+	done := make(chan string)
+	go func() {
+		token, _ := runner.oauth2Token(context.Background(), fast)
+		done <- token
+	}()
+	select {
+	case token := <-done:
+		if token != "token-fast-token" {
+			t.Errorf("Unexpected token %q", token)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Fetching one OAuth2 config blocked another")
+	}
+
+	close(release)
+	wg.Wait()
+	if slowTokens[0] != "token-slow-token" || slowTokens[1] != "token-slow-token" {
+		t.Errorf("Unexpected slow tokens: %v", slowTokens)
+	}
+	if fetches["/slow/token"] != 1 {
+		t.Errorf("Expected concurrent requests for one config to share a fetch, got %d fetches", fetches["/slow/token"])
+	}
+}
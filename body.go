@@ -0,0 +1,261 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 请求体类型，见 RequestConfig.BodyType
+const (
+	BodyTypeJSON      = "json"
+	BodyTypeForm      = "form"
+	BodyTypeMultipart = "multipart"
+	BodyTypeRaw       = "raw"
+	BodyTypeXML       = "xml"
+)
+
+// bodyType 返回请求体类型：优先 body_type，其次按 content_type 推断，再按配置的字段推断
+func (cfg RequestConfig) bodyType() string {
+	if cfg.BodyType != "" {
+		return cfg.BodyType
+	}
+	if cfg.ContentType != "" {
+		mediaType, _, _ := mime.ParseMediaType(cfg.ContentType)
+		switch {
+		case mediaType == "application/x-www-form-urlencoded":
+			return BodyTypeForm
+		case strings.HasPrefix(mediaType, "multipart/"):
+			return BodyTypeMultipart
+		case mediaType == "application/xml", mediaType == "text/xml", strings.HasSuffix(mediaType, "+xml"):
+			return BodyTypeXML
+		case isJSONMediaType(mediaType):
+			return BodyTypeJSON
+		}
+		return BodyTypeRaw
+	}
+	switch {
+	case len(cfg.Files) > 0:
+		return BodyTypeMultipart
+	case cfg.Raw != "", cfg.BodyFile != "":
+		return BodyTypeRaw
+	}
+	return BodyTypeJSON
+}
+
+// hasBody 是否配置了请求体
+func (cfg RequestConfig) hasBody() bool {
+	return cfg.Body != nil || cfg.Raw != "" || cfg.BodyFile != "" || len(cfg.Files) > 0
+}
+
+// buildBody 按请求体类型编码请求体，返回请求体和默认的 Content-Type
+func (r *TestRunner) buildBody(cfg RequestConfig) (*bytes.Buffer, string, error) {
+	var buf bytes.Buffer
+	switch bodyType := cfg.bodyType(); bodyType {
+	case BodyTypeJSON:
+		if cfg.Raw != "" || cfg.BodyFile != "" {
+			return r.rawBody(cfg, "application/json")
+		}
+		// 使用自定义 JSON 编码器，确保 int64 不会被序列化为科学计数法
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(r.replaceMapVariables(cfg.Body)); err != nil {
+			return nil, "", fmt.Errorf("failed to encode request body: %w", err)
+		}
+		return &buf, "application/json", nil
+
+	case BodyTypeForm:
+		form := url.Values{}
+		fields := r.replaceMapVariables(cfg.Body)
+		for _, key := range sortedKeys(fields) {
+			values, err := formValues(key, fields[key])
+			if err != nil {
+				return nil, "", err
+			}
+			for _, v := range values {
+				form.Add(key, v)
+			}
+		}
+		buf.WriteString(form.Encode())
+		return &buf, "application/x-www-form-urlencoded", nil
+
+	case BodyTypeMultipart:
+		return r.multipartBody(cfg)
+
+	case BodyTypeRaw:
+		return r.rawBody(cfg, "text/plain; charset=utf-8")
+
+	case BodyTypeXML:
+		if cfg.Raw != "" || cfg.BodyFile != "" {
+			return r.rawBody(cfg, "application/xml")
+		}
+		buf.WriteString(xml.Header)
+		if err := encodeXML(&buf, r.replaceMapVariables(cfg.Body)); err != nil {
+			return nil, "", fmt.Errorf("failed to encode request body: %w", err)
+		}
+		return &buf, "application/xml", nil
+
+	default:
+		return nil, "", fmt.Errorf("unknown body_type '%s': expected json, form, multipart, raw or xml", bodyType)
+	}
+}
+
+// rawBody 使用 raw（替换变量）或 body_file（原样读取）作为请求体
+func (r *TestRunner) rawBody(cfg RequestConfig, contentType string) (*bytes.Buffer, string, error) {
+	if cfg.BodyFile == "" {
+		return bytes.NewBufferString(r.replaceVariables(cfg.Raw)), contentType, nil
+	}
+	data, err := r.readFile(r.replaceVariables(cfg.BodyFile))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read body_file: %w", err)
+	}
+	if cfg.bodyType() == BodyTypeRaw {
+		contentType = "application/octet-stream"
+	}
+	return bytes.NewBuffer(data), contentType, nil
+}
+
+// multipartBody 编码 multipart/form-data：body 中的字段和 files 中的文件
+func (r *TestRunner) multipartBody(cfg RequestConfig) (*bytes.Buffer, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	fields := r.replaceMapVariables(cfg.Body)
+	for _, key := range sortedKeys(fields) {
+		values, err := formValues(key, fields[key])
+		if err != nil {
+			return nil, "", err
+		}
+		for _, v := range values {
+			if err := w.WriteField(key, v); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	names := make([]string, 0, len(cfg.Files))
+	for name := range cfg.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := r.replaceVariables(cfg.Files[name])
+		data, err := r.readFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read file for '%s': %w", name, err)
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(name), escapeQuotes(filepath.Base(path))))
+		contentType := mime.TypeByExtension(filepath.Ext(path))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header.Set("Content-Type", contentType)
+
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(data); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return &buf, w.FormDataContentType(), nil
+}
+
+// escapeQuotes 转义 Content-Disposition 中的引号和反斜杠
+func escapeQuotes(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// formValues 将表单字段值转换为字符串，数组展开为多个同名字段。表单没有嵌套结构，对象和嵌套数组返回错误
+func formValues(key string, v any) ([]string, error) {
+	items, isArray := v.([]any)
+	if !isArray {
+		items = []any{v}
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		switch item.(type) {
+		case nil:
+			out = append(out, "")
+		case map[string]any, map[any]any, []any:
+			return nil, fmt.Errorf("form field '%s' must be a scalar or an array of scalars", key)
+		default:
+			out = append(out, fmt.Sprint(item))
+		}
+	}
+	return out, nil
+}
+
+// encodeXML 将 body 编码为 XML：对象的键为元素名（按名称排序），数组展开为多个同名元素，
+// 以 @ 开头的键为属性，键 #text 为文本内容。顶层对象通常只有一个键作为根元素
+func encodeXML(buf *bytes.Buffer, body map[string]any) error {
+	enc := xml.NewEncoder(buf)
+	for _, key := range sortedKeys(body) {
+		if err := encodeXMLElement(enc, key, body[key]); err != nil {
+			return err
+		}
+	}
+	return enc.Flush()
+}
+
+// encodeXMLElement 编码单个元素
+func encodeXMLElement(enc *xml.Encoder, name string, value any) error {
+	if items, ok := value.([]any); ok {
+		for _, item := range items {
+			if err := encodeXMLElement(enc, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	obj, isObject := normalizeYAML(value).(map[string]any)
+	if isObject {
+		for _, key := range sortedKeys(obj) {
+			if attr, ok := strings.CutPrefix(key, "@"); ok {
+				start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: attr}, Value: fmt.Sprint(obj[key])})
+			}
+		}
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch {
+	case isObject:
+		for _, key := range sortedKeys(obj) {
+			switch {
+			case strings.HasPrefix(key, "@"):
+			case key == "#text":
+				if err := enc.EncodeToken(xml.CharData(fmt.Sprint(obj[key]))); err != nil {
+					return err
+				}
+			default:
+				if err := encodeXMLElement(enc, key, obj[key]); err != nil {
+					return err
+				}
+			}
+		}
+	case value != nil:
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(value))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
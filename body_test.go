@@ -0,0 +1,179 @@
+package apitest
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildBody(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "avatar.png"), []byte("PNGDATA"), 0644)
+	os.WriteFile(filepath.Join(dir, "payload.bin"), []byte("{{not replaced}}"), 0644)
+	runner := &TestRunner{configPath: filepath.Join(dir, "suite.yaml"), variables: Variables{"user": "john", "file": "avatar.png"}}
+
+	tests := []struct {
+		name     string
+		cfg      RequestConfig
+		wantType string
+		wantBody string
+	}{
+		{"json", RequestConfig{Body: map[string]any{"name": "{{user}}"}}, "application/json", `{"name":"john"}` + "\n"},
+		{"form", RequestConfig{BodyType: BodyTypeForm, Body: map[string]any{"user": "{{user}}", "tags": []any{"a", "b"}}}, "application/x-www-form-urlencoded", "tags=a&tags=b&user=john"},
+		{"form by content_type", RequestConfig{ContentType: "application/x-www-form-urlencoded", Body: map[string]any{"q": "a b"}}, "application/x-www-form-urlencoded", "q=a+b"},
+		{"raw", RequestConfig{Raw: "hello {{user}}"}, "text/plain; charset=utf-8", "hello john"},
+		{"raw json", RequestConfig{BodyType: BodyTypeJSON, Raw: `[1,"{{user}}"]`}, "application/json", `[1,"john"]`},
+		{"body_file", RequestConfig{BodyFile: "payload.bin"}, "application/octet-stream", "{{not replaced}}"},
+		{"xml", RequestConfig{BodyType: BodyTypeXML, Body: map[string]any{
+			"order": map[string]any{"@id": 7, "customer": "{{user}}", "item": []any{"a", map[string]any{"@qty": 2, "#text": "b"}}},
+		}}, "application/xml", `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<order id="7"><customer>john</customer><item>a</item><item qty="2">b</item></order>`},
+		{"raw xml", RequestConfig{ContentType: "application/soap+xml", Raw: "<Envelope/>"}, "application/xml", "<Envelope/>"},
+	}
+	for _, tt := range tests {
+		buf, contentType, err := runner.buildBody(tt.cfg)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if contentType != tt.wantType {
+			t.Errorf("%s: expected content type %q, got %q", tt.name, tt.wantType, contentType)
+		}
+		if buf.String() != tt.wantBody {
+			t.Errorf("%s: expected body %q, got %q", tt.name, tt.wantBody, buf.String())
+		}
+	}
+
+	if _, _, err := runner.buildBody(RequestConfig{BodyType: "yaml", Body: map[string]any{}}); err == nil || !strings.Contains(err.Error(), "unknown body_type 'yaml'") {
+		t.Errorf("Expected unknown body_type error, got %v", err)
+	}
+	for _, cfg := range []RequestConfig{
+		{BodyType: BodyTypeForm, Body: map[string]any{"address": map[string]any{"city": "Paris"}}},
+		{BodyType: BodyTypeMultipart, Body: map[string]any{"address": []any{[]any{"Paris"}}}},
+	} {
+		if _, _, err := runner.buildBody(cfg); err == nil || !strings.Contains(err.Error(), "form field 'address' must be a scalar or an array of scalars") {
+			t.Errorf("Expected nested %s field error, got %v", cfg.BodyType, err)
+		}
+	}
+	if _, _, err := runner.buildBody(RequestConfig{Files: map[string]string{"doc": "missing.pdf"}}); err == nil || !strings.Contains(err.Error(), "failed to read file for 'doc'") {
+		t.Errorf("Expected missing file error, got %v", err)
+	}
+}
+
+func TestTestRunnerMultipartUpload(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "avatar.png"), []byte("PNGDATA"), 0644)
+	configPath := filepath.Join(dir, "upload.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "Upload"
+variables: { user_id: 42 }
+scenarios:
+  - name: "Files"
+    testcases:
+      - name: "Upload Avatar"
+        request:
+          method: POST
+          path: /avatars
+          body: { user_id: "{{user_id}}", public: true }
+          files: { avatar: avatar.png }
+        expect: { status_code: 201 }
+      - name: "Override Content-Type"
+        request:
+          method: POST
+          path: /echo
+          raw: "a,b"
+          content_type: text/csv
+        expect: { status_code: 200, body: { contains: ["text/csv"] } }
+`), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("NewTestRunner failed: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	runner.SetHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/echo" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"content_type":"` + r.Header.Get("Content-Type") + `"}`))
+			return
+		}
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "multipart/form-data" {
+			http.Error(w, "expected multipart, got "+mediaType, http.StatusBadRequest)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		file, header, err := r.FormFile("avatar")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		if r.FormValue("user_id") != "42" || r.FormValue("public") != "true" || header.Filename != "avatar.png" ||
+			header.Header.Get("Content-Type") != "image/png" || string(data) != "PNGDATA" {
+			http.Error(w, "unexpected upload", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, result := range runner.GetResults() {
+		if !result.Passed {
+			t.Errorf("Expected %s to pass, got %s", result.Name, result.Error)
+		}
+	}
+}
+
+func TestMockServerFormBody(t *testing.T) {
+	suite, err := ParseSuite([]byte(`
+suite:
+  name: "Form Login"
+mocks:
+  - name: "Login"
+    request: { method: POST, path: /login, body: { username: "john" } }
+    response: { status: 200, body: { token: "t-{{body.username}}" } }
+scenarios:
+  - name: "Auth"
+    testcases:
+      - name: "Login With Form"
+        request: { method: POST, path: /login, body_type: form, body: { username: john, password: secret } }
+        expect:
+          status_code: 200
+          assertions:
+            - { path: "token", operator: "equals", value: "t-john" }
+      - name: "Login With Multipart"
+        request: { method: POST, path: /login, body_type: multipart, body: { username: john } }
+        expect: { status_code: 200 }
+`))
+	if err != nil {
+		t.Fatalf("ParseSuite failed: %v", err)
+	}
+	mock := NewMockServer(suite)
+	mock.SetOutput(io.Discard)
+
+	runner, err := NewTestRunnerFromSuite(suite, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("NewTestRunnerFromSuite failed: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	runner.SetHandler(mock)
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, result := range runner.GetResults() {
+		if !result.Passed {
+			t.Errorf("Expected %s to pass, got %s", result.Name, result.Error)
+		}
+	}
+}
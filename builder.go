@@ -0,0 +1,279 @@
+package apitest
+
+// SuiteBuilder 以代码方式构建测试套件
+//
+//	suite := apitest.NewSuite("User API").
+//		BaseURL("http://localhost:8080").
+//		Variable("username", "tester").
+//		Scenario(apitest.NewScenario("用户管理").
+//			Case(apitest.NewCase("创建用户").
+//				Post("/users", map[string]any{"username": "{{username}}"}).
+//				ExpectStatus(201).
+//				Assert("data.id", "notEmpty", nil).
+//				Save("user_id", "data.id"))).
+//		Build()
+type SuiteBuilder struct {
+	suite TestSuite
+}
+
+// NewSuite 创建套件构建器
+func NewSuite(name string) *SuiteBuilder {
+	return &SuiteBuilder{suite: TestSuite{Suite: SuiteConfig{Name: name}, Variables: Variables{}}}
+}
+
+// BaseURL 设置 base_url
+func (b *SuiteBuilder) BaseURL(url string) *SuiteBuilder {
+	b.suite.Suite.BaseURL = url
+	return b
+}
+
+// Variable 设置套件变量
+func (b *SuiteBuilder) Variable(name string, value any) *SuiteBuilder {
+	b.suite.Variables[name] = value
+	return b
+}
+
+// Setup 追加 setup 动作
+func (b *SuiteBuilder) Setup(actions ...SetupAction) *SuiteBuilder {
+	b.suite.Suite.Setup = append(b.suite.Suite.Setup, actions...)
+	return b
+}
+
+// Teardown 追加 teardown 动作
+func (b *SuiteBuilder) Teardown(actions ...SetupAction) *SuiteBuilder {
+	b.suite.Suite.Teardown = append(b.suite.Suite.Teardown, actions...)
+	return b
+}
+
+// OpenAPI 设置 OpenAPI 规范文件和校验模式（strict 或 warn，空字符串按 strict 处理）
+func (b *SuiteBuilder) OpenAPI(path, mode string) *SuiteBuilder {
+	b.suite.Suite.OpenAPI = path
+	b.suite.Suite.OpenAPIMode = mode
+	return b
+}
+
+// CookieJar 设置 Cookie 的共享范围：suite、scenario 或 none
+func (b *SuiteBuilder) CookieJar(mode string) *SuiteBuilder {
+	b.suite.Suite.CookieJar = mode
+	return b
+}
+
+// Auth 设置所有请求使用的认证
+func (b *SuiteBuilder) Auth(auth AuthConfig) *SuiteBuilder {
+	b.suite.Suite.Auth = &auth
+	return b
+}
+
+// Scenario 追加场景
+func (b *SuiteBuilder) Scenario(scenarios ...*ScenarioBuilder) *SuiteBuilder {
+	for _, s := range scenarios {
+		b.suite.Scenarios = append(b.suite.Scenarios, s.Build())
+	}
+	return b
+}
+
+// Mock 追加模拟接口
+func (b *SuiteBuilder) Mock(mocks ...Mock) *SuiteBuilder {
+	b.suite.Mocks = append(b.suite.Mocks, mocks...)
+	return b
+}
+
+// Build 返回构建的套件，可交给 NewTestRunnerFromSuite 运行或用 WriteSuite 输出为 YAML
+func (b *SuiteBuilder) Build() *TestSuite {
+	suite := b.suite
+	suite.Variables = make(Variables, len(b.suite.Variables))
+	for k, v := range b.suite.Variables {
+		suite.Variables[k] = v
+	}
+	suite.Scenarios = append([]Scenario(nil), b.suite.Scenarios...)
+	suite.normalize()
+	return &suite
+}
+
+// ScenarioBuilder 场景构建器
+type ScenarioBuilder struct {
+	scenario Scenario
+}
+
+// NewScenario 创建场景构建器
+func NewScenario(name string) *ScenarioBuilder {
+	return &ScenarioBuilder{scenario: Scenario{Name: name}}
+}
+
+// Description 设置场景描述
+func (b *ScenarioBuilder) Description(description string) *ScenarioBuilder {
+	b.scenario.Description = description
+	return b
+}
+
+// Parallel 标记为 parallel 场景
+func (b *ScenarioBuilder) Parallel() *ScenarioBuilder {
+	b.scenario.Parallel = true
+	return b
+}
+
+// Case 追加测试用例
+func (b *ScenarioBuilder) Case(cases ...*CaseBuilder) *ScenarioBuilder {
+	for _, c := range cases {
+		b.scenario.TestCases = append(b.scenario.TestCases, c.Build())
+	}
+	return b
+}
+
+// Build 返回构建的场景
+func (b *ScenarioBuilder) Build() Scenario {
+	scenario := b.scenario
+	scenario.TestCases = append([]TestCase(nil), b.scenario.TestCases...)
+	return scenario
+}
+
+// CaseBuilder 测试用例构建器
+type CaseBuilder struct {
+	tc TestCase
+}
+
+// NewCase 创建用例构建器
+func NewCase(name string) *CaseBuilder {
+	return &CaseBuilder{tc: TestCase{Name: name}}
+}
+
+// Request 设置请求方法和路径
+func (b *CaseBuilder) Request(method, path string) *CaseBuilder {
+	b.tc.Request.Method = method
+	b.tc.Request.Path = path
+	return b
+}
+
+// Get 设置 GET 请求
+func (b *CaseBuilder) Get(path string) *CaseBuilder {
+	return b.Request("GET", path)
+}
+
+// Post 设置 POST 请求和 JSON 请求体
+func (b *CaseBuilder) Post(path string, body map[string]any) *CaseBuilder {
+	return b.Request("POST", path).Body(body)
+}
+
+// Put 设置 PUT 请求和 JSON 请求体
+func (b *CaseBuilder) Put(path string, body map[string]any) *CaseBuilder {
+	return b.Request("PUT", path).Body(body)
+}
+
+// Patch 设置 PATCH 请求和 JSON 请求体
+func (b *CaseBuilder) Patch(path string, body map[string]any) *CaseBuilder {
+	return b.Request("PATCH", path).Body(body)
+}
+
+// Delete 设置 DELETE 请求
+func (b *CaseBuilder) Delete(path string) *CaseBuilder {
+	return b.Request("DELETE", path)
+}
+
+// Header 设置请求头
+func (b *CaseBuilder) Header(name, value string) *CaseBuilder {
+	if b.tc.Request.Headers == nil {
+		b.tc.Request.Headers = make(map[string]string)
+	}
+	b.tc.Request.Headers[name] = value
+	return b
+}
+
+// Query 设置查询参数
+func (b *CaseBuilder) Query(name, value string) *CaseBuilder {
+	if b.tc.Request.Query == nil {
+		b.tc.Request.Query = make(map[string]string)
+	}
+	b.tc.Request.Query[name] = value
+	return b
+}
+
+// Body 设置 JSON 请求体
+func (b *CaseBuilder) Body(body map[string]any) *CaseBuilder {
+	b.tc.Request.Body = body
+	return b
+}
+
+// DependsOn 设置依赖的用例
+func (b *CaseBuilder) DependsOn(name string) *CaseBuilder {
+	b.tc.DependsOn = name
+	return b
+}
+
+// ExpectStatus 设置期望的状态码
+func (b *CaseBuilder) ExpectStatus(code int) *CaseBuilder {
+	b.tc.Expect.StatusCode = code
+	return b
+}
+
+// ExpectField 设置 response_body 中期望的字段值
+func (b *CaseBuilder) ExpectField(key string, value any) *CaseBuilder {
+	if b.tc.Expect.ResponseBody == nil {
+		b.tc.Expect.ResponseBody = make(map[string]any)
+	}
+	b.tc.Expect.ResponseBody[key] = value
+	return b
+}
+
+// Schema 设置响应体的 JSON Schema，内联 schema 或文件路径
+func (b *CaseBuilder) Schema(schema any) *CaseBuilder {
+	b.tc.Expect.Schema = schema
+	return b
+}
+
+// Assert 追加断言
+func (b *CaseBuilder) Assert(path, operator string, value any) *CaseBuilder {
+	b.tc.Expect.Assertions = append(b.tc.Expect.Assertions, Assertion{Path: path, Operator: operator, Value: value})
+	return b
+}
+
+// Save 将响应中 path 的值保存为变量 name
+func (b *CaseBuilder) Save(name, path string) *CaseBuilder {
+	if b.tc.Save == nil {
+		b.tc.Save = make(map[string]string)
+	}
+	b.tc.Save[name] = path
+	return b
+}
+
+// Retry 设置重试次数和间隔（毫秒）
+func (b *CaseBuilder) Retry(times, interval int) *CaseBuilder {
+	b.tc.Retry = &RetryConfig{Times: times, Interval: interval}
+	return b
+}
+
+// Tags 追加用例标签
+func (b *CaseBuilder) Tags(tags ...string) *CaseBuilder {
+	b.tc.Tags = append(b.tc.Tags, tags...)
+	return b
+}
+
+// Session 设置用例使用的 Cookie 会话
+func (b *CaseBuilder) Session(name string) *CaseBuilder {
+	b.tc.Session = name
+	return b
+}
+
+// ResetCookies 发送请求前清空会话的 Cookie
+func (b *CaseBuilder) ResetCookies() *CaseBuilder {
+	b.tc.ResetCookies = true
+	return b
+}
+
+// Auth 设置用例使用的认证，覆盖套件配置
+func (b *CaseBuilder) Auth(auth AuthConfig) *CaseBuilder {
+	b.tc.Auth = &auth
+	return b
+}
+
+// NoAuth 用例不使用套件的认证
+func (b *CaseBuilder) NoAuth() *CaseBuilder {
+	return b.Auth(AuthConfig{Type: AuthNone})
+}
+
+// Build 返回构建的用例
+func (b *CaseBuilder) Build() TestCase {
+	tc := b.tc
+	tc.Expect.Assertions = append([]Assertion(nil), b.tc.Expect.Assertions...)
+	tc.Tags = append([]string(nil), b.tc.Tags...)
+	return tc
+}
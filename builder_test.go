@@ -0,0 +1,106 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSuiteBuilder(t *testing.T) {
+	suite := NewSuite("Built Suite").
+		BaseURL("http://api.test").
+		Variable("username", "builder").
+		Scenario(NewScenario("Users").
+			Description("create then read").
+			Case(
+				NewCase("Create User").
+					Post("/users", map[string]any{"username": "{{username}}"}).
+					Header("X-Trace", "t-1").
+					ExpectStatus(201).
+					Assert("message", "equals", "User created").
+					Save("user_id", "id"),
+				NewCase("Get User").
+					Get("/users/1").
+					Query("verbose", "true").
+					DependsOn("Create User").
+					ExpectStatus(200).
+					ExpectField("name", "John Doe").
+					Tags("smoke"),
+			)).
+		Build()
+
+	var buf bytes.Buffer
+	if err := WriteSuite(&buf, suite); err != nil {
+		t.Fatalf("WriteSuite failed: %v", err)
+	}
+	parsed, err := ReadSuite(&buf)
+	if err != nil {
+		t.Fatalf("ReadSuite failed: %v", err)
+	}
+	if !reflect.DeepEqual(parsed.Scenarios[0].TestCases[1], suite.Scenarios[0].TestCases[1]) {
+		t.Errorf("Expected YAML round trip to preserve test case, got %+v", parsed.Scenarios[0].TestCases[1])
+	}
+
+	mockSuite, _ := ParseSuite([]byte(mockServerSuite))
+	mock := NewMockServer(mockSuite)
+	mock.SetOutput(io.Discard)
+
+	runner, err := NewTestRunnerFromSuite(suite, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("NewTestRunnerFromSuite failed: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	runner.SetHandler(mock)
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, result := range runner.GetResults() {
+		if !result.Passed {
+			t.Errorf("Test %q failed: %s", result.Name, result.Error)
+		}
+	}
+	if runner.GetResults()[0].Saved["user_id"] == nil {
+		t.Errorf("Expected saved variable, got %+v", runner.GetResults()[0].Saved)
+	}
+}
+
+func TestNewTestRunnerFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"suites/users.yaml": {Data: []byte(`
+suite:
+  name: "Embedded"
+  openapi: "../specs/users.yaml"
+scenarios:
+  - name: "Users"
+    testcases:
+      - name: "Get User"
+        request: { method: "GET", path: "/users/1" }
+        expect: { status_code: 200, schema: "schemas/user.json" }
+`)},
+		"suites/schemas/user.json": {Data: []byte(`{"type": "object", "required": ["id", "name"]}`)},
+		"specs/users.yaml":         {Data: []byte(testOpenAPISpec)},
+	}
+
+	runner, err := NewTestRunnerFS(fsys, "suites/users.yaml", nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("NewTestRunnerFS failed: %v", err)
+	}
+	if runner.OpenAPISpec() == nil {
+		t.Fatal("Expected openapi spec loaded from fsys")
+	}
+	schema, err := runner.loadSchema("schemas/user.json")
+	if err != nil {
+		t.Fatalf("Expected schema loaded from fsys: %v", err)
+	}
+	if schema.(map[string]any)["type"] != "object" {
+		t.Errorf("Unexpected schema %v", schema)
+	}
+
+	if _, err := NewTestRunnerFS(fsys, "suites/missing.yaml", nil, nil); err == nil || !strings.Contains(err.Error(), "failed to read config") {
+		t.Errorf("Expected read error for missing suite, got %v", err)
+	}
+}
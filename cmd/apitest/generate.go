@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/yannick2025-tech/gwc-apitest"
+)
+
+// generateOptions generate 子命令的参数
+type generateOptions struct {
+	specPath     string
+	outputPath   string
+	name         string
+	baseURL      string
+	skipNegative bool
+}
+
+// runGenerate 从 OpenAPI 规范生成 YAML 测试套件，返回进程退出码
+func runGenerate(args []string, stdout io.Writer) int {
+	opts := &generateOptions{}
+	fs := flag.NewFlagSet("apitest generate", flag.ContinueOnError)
+	fs.StringVar(&opts.specPath, "openapi", "", "OpenAPI 3 规范文件路径（必填）")
+	fs.StringVar(&opts.outputPath, "o", "", "输出的 YAML 文件路径，为空时输出到标准输出")
+	fs.StringVar(&opts.name, "name", "", "套件名，默认使用规范的 info.title")
+	fs.StringVar(&opts.baseURL, "url", "", "API 基础 URL，默认使用规范 servers 中的第一个地址")
+	fs.BoolVar(&opts.skipNegative, "skip-negative", false, "不生成缺少必填字段、类型错误的反向用例")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if opts.specPath == "" {
+		fmt.Fprintln(fs.Output(), "-openapi is required")
+		fs.Usage()
+		return 2
+	}
+
+	spec, err := apitest.LoadOpenAPI(opts.specPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	suite := apitest.GenerateSuite(spec, apitest.GenerateOptions{
+		Name:         opts.name,
+		BaseURL:      opts.baseURL,
+		SkipNegative: opts.skipNegative,
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Generated from %s by apitest generate\n", filepath.Base(opts.specPath))
+	if err := apitest.WriteSuite(&buf, suite); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	if opts.outputPath == "" {
+		stdout.Write(buf.Bytes())
+		return 0
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.outputPath), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(opts.outputPath, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to write test suite: %v\n", err)
+		return 1
+	}
+
+	cases := 0
+	for _, scenario := range suite.Scenarios {
+		cases += len(scenario.TestCases)
+	}
+	fmt.Fprintf(stdout, "📝 Generated %d scenario(s), %d test case(s) from %d operation(s): %s\n",
+		len(suite.Scenarios), cases, len(spec.Operations), opts.outputPath)
+	return 0
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/yannick2025-tech/gwc-apitest"
+)
+
+// importOptions import 子命令的参数
+type importOptions struct {
+	collectionPath  string
+	environmentPath string
+	outputPath      string
+}
+
+// runImport 将 Postman 集合转换为 YAML 测试套件，返回进程退出码
+func runImport(args []string, stdout io.Writer) int {
+	opts := &importOptions{}
+	fs := flag.NewFlagSet("apitest import", flag.ContinueOnError)
+	fs.StringVar(&opts.collectionPath, "postman", "", "Postman v2.1 集合文件路径（必填）")
+	fs.StringVar(&opts.environmentPath, "env", "", "Postman 环境文件路径，转换为 variables")
+	fs.StringVar(&opts.outputPath, "o", "", "输出的 YAML 文件路径，为空时输出到标准输出")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if opts.collectionPath == "" {
+		fmt.Fprintln(fs.Output(), "-postman is required")
+		fs.Usage()
+		return 2
+	}
+
+	collection, err := os.ReadFile(opts.collectionPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to read collection: %v\n", err)
+		return 1
+	}
+	var environment []byte
+	if opts.environmentPath != "" {
+		if environment, err = os.ReadFile(opts.environmentPath); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to read environment: %v\n", err)
+			return 1
+		}
+	}
+
+	suite, warnings, err := apitest.ImportPostman(collection, environment)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Imported from %s by apitest import\n", filepath.Base(opts.collectionPath))
+	if err := apitest.WriteSuite(&buf, suite); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	if len(warnings) > 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  %d construct(s) could not be translated:\n", len(warnings))
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "   - %s\n", w)
+		}
+	}
+
+	if opts.outputPath == "" {
+		stdout.Write(buf.Bytes())
+		return 0
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.outputPath), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(opts.outputPath, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to write test suite: %v\n", err)
+		return 1
+	}
+
+	cases := 0
+	for _, scenario := range suite.Scenarios {
+		cases += len(scenario.TestCases)
+	}
+	fmt.Fprintf(stdout, "📥 Imported %d scenario(s), %d test case(s): %s\n", len(suite.Scenarios), cases, opts.outputPath)
+	return 0
+}
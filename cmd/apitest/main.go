@@ -0,0 +1,285 @@
+// Command apitest 基于 YAML 配置运行 API 集成测试
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/yannick2025-tech/gwc-apitest"
+	"github.com/yannick2025-tech/gwc-db"
+	"github.com/yannick2025-tech/gwc-logging"
+)
+
+// defaultBaseURL 配置文件和命令行都未指定 base_url 时使用的地址
+const defaultBaseURL = "http://localhost:8080"
+
+// options 命令行参数
+type options struct {
+	configPath string
+	pattern    string
+	recursive  bool
+	dsn        string
+	baseURL    string
+	exportDir  string
+	junitPath  string
+	htmlPath   string
+	parallel   int
+	soft       bool // 所有用例使用 soft 断言
+
+	openapiPath string               // 覆盖配置文件中的 openapi
+	openapiMode string               // 覆盖配置文件中的 openapi_mode
+	spec        *apitest.OpenAPISpec // 由 openapiPath 加载
+	coverage    bool                 // 运行结束后打印覆盖率报告
+	coverageMin float64              // 操作或响应码覆盖率低于该百分比时返回非零退出码
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run 解析参数并运行测试，返回进程退出码
+// 第一个参数为子命令时执行对应的子命令：generate 从 OpenAPI 规范生成测试套件，import 导入 Postman 集合，
+// record 通过代理录制请求，mock 根据测试套件启动模拟服务，operators 列出断言操作符
+func run(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "generate":
+			return runGenerate(args[1:], os.Stdout)
+		case "import":
+			return runImport(args[1:], os.Stdout)
+		case "record":
+			return runRecord(args[1:], os.Stdout)
+		case "mock":
+			return runMock(args[1:], os.Stdout)
+		case "operators":
+			return runOperators(args[1:], os.Stdout)
+		}
+	}
+
+	opts, err := parseFlags(args)
+	if err != nil {
+		return 2
+	}
+
+	if opts.openapiPath != "" {
+		if opts.spec, err = apitest.LoadOpenAPI(opts.openapiPath); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return 1
+		}
+	}
+
+	files, err := findTestFiles(opts.configPath, opts.pattern, opts.recursive)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	if len(files) == 0 {
+		fmt.Printf("❌ No test configuration files found in: %s\n", opts.configPath)
+		return 1
+	}
+
+	adapter, cleanup, err := newCleanupHandler(opts.dsn)
+	if err != nil {
+		fmt.Printf("❌ Failed to connect database: %v\n", err)
+		return 1
+	}
+	if adapter != nil {
+		defer adapter.Close()
+	}
+
+	printHeader(opts, files, adapter != nil)
+
+	fileResults := runFiles(context.Background(), files, opts, adapter, cleanup)
+
+	failed := printOverallSummary(fileResults)
+
+	if opts.exportDir != "" {
+		if err := exportResults(opts.exportDir, fileResults); err != nil {
+			fmt.Printf("❌ Failed to export results: %v\n", err)
+			return 1
+		}
+	}
+
+	if opts.junitPath != "" {
+		if err := exportReport(opts.junitPath, fileResults, apitest.ExportJUnit); err != nil {
+			fmt.Printf("❌ Failed to export JUnit report: %v\n", err)
+			return 1
+		}
+		fmt.Printf("📄 JUnit report exported to: %s\n", opts.junitPath)
+	}
+
+	if opts.htmlPath != "" {
+		if err := exportReport(opts.htmlPath, fileResults, apitest.ExportHTML); err != nil {
+			fmt.Printf("❌ Failed to export HTML report: %v\n", err)
+			return 1
+		}
+		fmt.Printf("📄 HTML report exported to: %s\n", opts.htmlPath)
+	}
+
+	code := 0
+	if opts.coverage && !printCoverage(opts, fileResults) {
+		code = 1
+	}
+
+	for _, fr := range fileResults {
+		if fr.err != nil {
+			return 1
+		}
+	}
+	if failed > 0 {
+		return 1
+	}
+	return code
+}
+
+// parseFlags 解析命令行参数
+func parseFlags(args []string) (*options, error) {
+	opts := &options{}
+	fs := flag.NewFlagSet("apitest", flag.ContinueOnError)
+	fs.StringVar(&opts.configPath, "config", "", "测试配置文件或目录路径（必填）")
+	fs.StringVar(&opts.pattern, "pattern", "*.yaml", "目录模式下的文件匹配模式")
+	fs.BoolVar(&opts.recursive, "recursive", true, "是否递归搜索子目录")
+	fs.StringVar(&opts.dsn, "db", "", "数据库 DSN（用于数据清理），为空时使用 Mock 清理")
+	fs.StringVar(&opts.baseURL, "url", "", "API 基础 URL，覆盖配置文件中的 base_url（默认 "+defaultBaseURL+"）")
+	fs.StringVar(&opts.exportDir, "export", "", "导出结果的目录路径")
+	fs.StringVar(&opts.junitPath, "junit", "", "JUnit XML 报告输出路径")
+	fs.StringVar(&opts.htmlPath, "html", "", "HTML 报告输出路径")
+	fs.IntVar(&opts.parallel, "parallel", 1, "同时运行的文件和 parallel 场景的总数上限")
+	fs.BoolVar(&opts.soft, "soft", false, "执行用例的全部校验项后再报告失败（用例的 expect.soft 优先）")
+	fs.StringVar(&opts.openapiPath, "openapi", "", "OpenAPI 3 规范文件路径，覆盖配置文件中的 openapi")
+	fs.StringVar(&opts.openapiMode, "openapi-mode", "", "契约校验模式：strict 或 warn，覆盖配置文件中的 openapi_mode")
+	fs.BoolVar(&opts.coverage, "coverage", false, "运行结束后打印 OpenAPI 规范的覆盖率报告（-openapi 或配置文件中的 openapi）")
+	fs.Float64Var(&opts.coverageMin, "coverage-min", 0, "操作覆盖率或响应码覆盖率低于该百分比时运行失败（隐含 -coverage）")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if opts.coverageMin > 0 {
+		opts.coverage = true
+	}
+	if opts.configPath == "" {
+		fmt.Fprintln(fs.Output(), "-config is required")
+		fs.Usage()
+		return nil, fmt.Errorf("config is required")
+	}
+	return opts, nil
+}
+
+// newCleanupHandler 根据 DSN 创建清理处理器，DSN 为空时使用 Mock 清理
+func newCleanupHandler(dsn string) (*db.XormAdapter, apitest.CleanupHandler, error) {
+	if dsn == "" {
+		return nil, &apitest.MockCleanupHandler{}, nil
+	}
+
+	logCfg := logging.DefaultConfig()
+	logCfg.LevelStr = "warn"
+	logger, err := logging.NewZapLogger(logCfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create logger failed: %w", err)
+	}
+
+	dbCfg := db.DefaultDBConfig()
+	dbCfg.DSN = dsn
+	dbCfg.ShowSQL = false
+	dbCfg.LogLevel = "warn"
+
+	adapter, err := db.NewXormAdapter(dbCfg, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	return adapter, apitest.NewDBCleanupHandler(adapter), nil
+}
+
+// exportResults 将每个文件的结果分别导出为 JSON
+func exportResults(dir string, fileResults []*fileResult) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n📄 Test results exported to: %s\n", dir)
+	for _, fr := range fileResults {
+		if fr.runner == nil {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(fr.file), filepath.Ext(fr.file))
+		path := filepath.Join(dir, name+"_results.json")
+		if err := fr.runner.ExportResults(path); err != nil {
+			return fmt.Errorf("export %s: %w", path, err)
+		}
+		fmt.Printf("  ✓ Exported: %s\n", path)
+	}
+	return nil
+}
+
+// printCoverage 打印覆盖率报告，操作或响应码覆盖率低于 -coverage-min 时返回 false
+// 未指定 -openapi 时使用各文件配置的 openapi，按规范的 title 和 version 分组统计
+func printCoverage(opts *options, fileResults []*fileResult) bool {
+	type group struct {
+		spec    *apitest.OpenAPISpec
+		runners []*apitest.TestRunner
+	}
+	var groups []*group
+	for _, fr := range fileResults {
+		if fr.runner == nil {
+			continue
+		}
+		spec := opts.spec
+		if spec == nil {
+			spec = fr.runner.OpenAPISpec()
+		}
+		if spec == nil {
+			continue
+		}
+		i := slices.IndexFunc(groups, func(g *group) bool {
+			return g.spec == spec || g.spec.Title == spec.Title && g.spec.Version == spec.Version
+		})
+		if i < 0 {
+			i = len(groups)
+			groups = append(groups, &group{spec: spec})
+		}
+		groups[i].runners = append(groups[i].runners, fr.runner)
+	}
+	if len(groups) == 0 {
+		fmt.Println("❌ -coverage requires -openapi or openapi in the test configuration")
+		return false
+	}
+
+	ok := true
+	for _, g := range groups {
+		if len(groups) > 1 {
+			fmt.Printf("\n📘 %s %s\n", g.spec.Title, g.spec.Version)
+		}
+		report := apitest.ComputeCoverage(g.spec, g.runners...)
+		apitest.WriteCoverage(os.Stdout, report)
+
+		if percent := report.OperationPercent(); percent < opts.coverageMin {
+			fmt.Printf("❌ Operation coverage %.2f%% is below the required %.2f%%\n", percent, opts.coverageMin)
+			ok = false
+		}
+		if percent := report.ResponsePercent(); percent < opts.coverageMin {
+			fmt.Printf("❌ Response code coverage %.2f%% is below the required %.2f%%\n", percent, opts.coverageMin)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// exportReport 使用 export 将所有文件的结果导出为一个汇总报告（JUnit、HTML 等）
+func exportReport(path string, fileResults []*fileResult, export func(string, ...*apitest.TestRunner) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var runners []*apitest.TestRunner
+	for _, fr := range fileResults {
+		if fr.runner != nil {
+			runners = append(runners, fr.runner)
+		}
+	}
+	return export(path, runners...)
+}
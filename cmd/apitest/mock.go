@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/yannick2025-tech/gwc-apitest"
+)
+
+// runMock 根据测试套件启动模拟服务，收到中断信号后退出，返回进程退出码
+func runMock(args []string, stdout io.Writer) int {
+	fs := flag.NewFlagSet("apitest mock", flag.ContinueOnError)
+	listen := fs.String("listen", ":8080", "模拟服务监听地址")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(fs.Output(), "at least one suite file is required")
+		fs.Usage()
+		return 2
+	}
+
+	var suites []*apitest.TestSuite
+	for _, path := range fs.Args() {
+		suite, err := apitest.LoadSuite(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", path, err)
+			return 1
+		}
+		suites = append(suites, suite)
+	}
+
+	mock := apitest.NewMockServer(suites...)
+	mock.SetOutput(stdout)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: *listen, Handler: mock}
+	errs := make(chan error, 1)
+	go func() { errs <- server.ListenAndServe() }()
+
+	fmt.Fprintf(stdout, "🎭 Mocking %d suite(s) on %s, press Ctrl+C to stop\n", len(suites), *listen)
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "❌ Mock server failed: %v\n", err)
+			return 1
+		}
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}
+	return 0
+}
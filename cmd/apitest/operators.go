@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/yannick2025-tech/gwc-apitest"
+)
+
+// runOperators 列出可在 assertions 中使用的断言操作符，返回进程退出码
+func runOperators(args []string, stdout io.Writer) int {
+	fs := flag.NewFlagSet("apitest operators", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OPERATOR\tDESCRIPTION")
+	for _, op := range apitest.Operators() {
+		desc := op.Description
+		if !op.Builtin {
+			desc += " (custom)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", op.Name, desc)
+	}
+	tw.Flush()
+	return 0
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/yannick2025-tech/gwc-apitest"
+)
+
+// recordOptions record 子命令的参数
+type recordOptions struct {
+	target     string
+	listen     string
+	outputPath string
+	name       string
+}
+
+// runRecord 启动录制代理，收到中断信号后将记录的请求写入 YAML 测试套件，返回进程退出码
+func runRecord(args []string, stdout io.Writer) int {
+	opts := &recordOptions{}
+	fs := flag.NewFlagSet("apitest record", flag.ContinueOnError)
+	fs.StringVar(&opts.target, "target", "", "被录制服务的地址，例如 http://localhost:8080（必填）")
+	fs.StringVar(&opts.listen, "listen", ":8081", "代理监听地址")
+	fs.StringVar(&opts.outputPath, "o", "", "输出的 YAML 文件路径（必填）")
+	fs.StringVar(&opts.name, "name", "Recorded", "套件名和场景名")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if opts.target == "" || opts.outputPath == "" {
+		fmt.Fprintln(fs.Output(), "-target and -o are required")
+		fs.Usage()
+		return 2
+	}
+
+	recorder, err := apitest.NewRecorder(opts.target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: opts.listen, Handler: recorder}
+	errs := make(chan error, 1)
+	go func() { errs <- server.ListenAndServe() }()
+
+	fmt.Fprintf(stdout, "🎙️  Recording %s on %s, press Ctrl+C to stop\n", opts.target, opts.listen)
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "❌ Proxy failed: %v\n", err)
+			return 1
+		}
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}
+
+	suite := recorder.Suite(opts.name)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Recorded from %s by apitest record\n", opts.target)
+	if err := apitest.WriteSuite(&buf, suite); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.outputPath), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(opts.outputPath, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to write test suite: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "\n📼 Recorded %d request(s): %s\n", len(suite.Scenarios[0].TestCases), opts.outputPath)
+	return 0
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/yannick2025-tech/gwc-apitest"
+	"github.com/yannick2025-tech/gwc-db"
+)
+
+// fileResult 单个测试文件的运行结果
+type fileResult struct {
+	file   string
+	runner *apitest.TestRunner
+	err    error // 加载或运行失败时的错误
+}
+
+// passedFailed 统计通过和失败的用例数
+func (fr *fileResult) passedFailed() (passed, failed int) {
+	if fr.runner == nil {
+		return 0, 0
+	}
+	for _, result := range fr.runner.GetResults() {
+		if result.Passed {
+			passed++
+		} else {
+			failed++
+		}
+	}
+	return passed, failed
+}
+
+// findTestFiles 查找测试配置文件
+// configPath 为文件时直接返回，为目录时按 pattern 匹配文件名
+func findTestFiles(configPath, pattern string, recursive bool) ([]string, error) {
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("config path not found: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{configPath}, nil
+	}
+
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	var files []string
+	err = filepath.WalkDir(configPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != configPath && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matched, _ := filepath.Match(pattern, d.Name()); matched {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// runFiles 运行所有测试文件
+// opts.parallel 大于 1 时使用 worker 池并发运行，每个文件的输出先写入缓冲，
+// 再按文件顺序打印，保证输出与顺序运行时一致。文件和 parallel 场景共享同一组令牌，
+// 同时运行的文件和场景总数不超过 opts.parallel
+func runFiles(ctx context.Context, files []string, opts *options, adapter *db.XormAdapter, cleanup apitest.CleanupHandler) []*fileResult {
+	fileResults := make([]*fileResult, len(files))
+	if opts.parallel <= 1 {
+		for i, file := range files {
+			fileResults[i] = runFile(ctx, file, opts, adapter, cleanup, os.Stdout, nil)
+		}
+		return fileResults
+	}
+
+	outputs := make([]bytes.Buffer, len(files))
+	done := make([]chan struct{}, len(files))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	slots := make(chan struct{}, opts.parallel)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(opts.parallel, len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				slots <- struct{}{}
+				fileResults[i] = runFile(ctx, files[i], opts, adapter, cleanup, &outputs[i], slots)
+				<-slots
+				close(done[i])
+			}
+		}()
+	}
+	go func() {
+		for i := range files {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	// 按顺序打印已完成文件的输出
+	for i := range files {
+		<-done[i]
+		os.Stdout.Write(outputs[i].Bytes())
+	}
+	wg.Wait()
+	return fileResults
+}
+
+// runFile 加载并运行单个测试文件，输出写入 w
+// slots 不为 nil 时调用方已为该文件占用一个令牌，parallel 场景从中获取额外的令牌
+func runFile(ctx context.Context, file string, opts *options, adapter *db.XormAdapter, cleanup apitest.CleanupHandler, w io.Writer, slots chan struct{}) *fileResult {
+	fmt.Fprintf(w, "\n╔═══════════════════════════════════════════════════════╗\n")
+	fmt.Fprintf(w, "║ 📄 Running: %-41s ║\n", filepath.Base(file))
+	fmt.Fprintf(w, "╚═══════════════════════════════════════════════════════╝\n\n")
+
+	fr := &fileResult{file: file}
+
+	// adapter 为 nil 时必须传入无类型的 nil，避免接口持有空指针
+	var dbAdapter db.DBAdapter
+	if adapter != nil {
+		dbAdapter = adapter
+	}
+
+	runner, err := apitest.NewTestRunner(file, dbAdapter, cleanup)
+	if err != nil {
+		fmt.Fprintf(w, "❌ Failed to load test configuration: %v\n", err)
+		fr.err = err
+		return fr
+	}
+	fr.runner = runner
+	runner.SetOutput(w)
+	runner.SetParallelism(opts.parallel)
+	if slots != nil {
+		runner.ShareParallelism(slots)
+	}
+	if opts.soft {
+		runner.SetSoftAssertions(true)
+	}
+
+	if opts.spec != nil {
+		runner.SetOpenAPISpec(opts.spec)
+	}
+	if opts.openapiMode != "" {
+		if err := runner.SetOpenAPIMode(opts.openapiMode); err != nil {
+			fmt.Fprintf(w, "❌ %v\n", err)
+			fr.err = err
+			return fr
+		}
+	}
+
+	if opts.baseURL != "" {
+		runner.SetBaseURL(opts.baseURL)
+	} else if runner.BaseURL() == "" {
+		runner.SetBaseURL(defaultBaseURL)
+	}
+
+	if err := runner.Run(ctx); err != nil {
+		fmt.Fprintf(w, "❌ Failed to run test suite: %v\n", err)
+		fr.err = err
+	}
+	return fr
+}
+
+// printHeader 打印运行信息
+func printHeader(opts *options, files []string, dbEnabled bool) {
+	fmt.Println("════════════════════════════════════════════════════════")
+	fmt.Println("🧪 API Integration Test Runner")
+	fmt.Println("════════════════════════════════════════════════════════")
+	fmt.Printf("📁 Config Path: %s\n", opts.configPath)
+	fmt.Printf("🔍 Pattern: %s\n", opts.pattern)
+	if dbEnabled {
+		fmt.Println("🗄️  Database: Connected (cleanup enabled)")
+	} else {
+		fmt.Println("🗄️  Database: Not configured (mock cleanup)")
+	}
+	fmt.Printf("📋 Found %d test file(s):\n", len(files))
+	for i, file := range files {
+		fmt.Printf("   %d. %s\n", i+1, file)
+	}
+	fmt.Println("════════════════════════════════════════════════════════")
+}
+
+// printOverallSummary 打印所有文件的汇总结果，返回失败用例数
+func printOverallSummary(fileResults []*fileResult) int {
+	totalPassed := 0
+	totalFailed := 0
+	for _, fr := range fileResults {
+		passed, failed := fr.passedFailed()
+		totalPassed += passed
+		totalFailed += failed
+	}
+
+	fmt.Printf("\n\n╔═══════════════════════════════════════════════════════╗\n")
+	fmt.Printf("║                  📊 OVERALL SUMMARY                    ║\n")
+	fmt.Printf("╚═══════════════════════════════════════════════════════╝\n\n")
+
+	fmt.Printf("📁 Test Files: %d\n", len(fileResults))
+	fmt.Printf("✅ Total Passed: %d\n", totalPassed)
+	fmt.Printf("❌ Total Failed: %d\n", totalFailed)
+	if total := totalPassed + totalFailed; total > 0 {
+		fmt.Printf("📈 Success Rate: %.2f%%\n", float64(totalPassed)*100/float64(total))
+	}
+
+	var loadErrors []*fileResult
+	for _, fr := range fileResults {
+		if fr.err != nil {
+			loadErrors = append(loadErrors, fr)
+		}
+	}
+	if len(loadErrors) > 0 {
+		fmt.Printf("\n⚠️  Files With Errors:\n\n")
+		for _, fr := range loadErrors {
+			fmt.Printf("  📄 %s\n", filepath.Base(fr.file))
+			fmt.Printf("     %v\n", fr.err)
+		}
+	}
+
+	if totalFailed > 0 {
+		fmt.Printf("\n❌ Failed Tests by File:\n")
+		for _, fr := range fileResults {
+			_, failed := fr.passedFailed()
+			if failed == 0 {
+				continue
+			}
+			fmt.Printf("\n  📄 %s (%d failed)\n", filepath.Base(fr.file), failed)
+			for _, result := range fr.runner.GetResults() {
+				if !result.Passed {
+					fmt.Printf("     ✗ [%s] %s\n", result.Scenario, result.Name)
+					fmt.Printf("       %s\n", result.Error)
+				}
+			}
+		}
+	}
+
+	fmt.Println("\n════════════════════════════════════════════════════════")
+	return totalFailed
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindTestFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "user"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "a_test.yaml"), []byte("suite: {}"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "notes.txt"), []byte("ignored"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "user", "user_test.yaml"), []byte("suite: {}"), 0644)
+
+	files, err := findTestFiles(tempDir, "*.yaml", true)
+	if err != nil {
+		t.Fatalf("findTestFiles failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("Expected 2 files with recursive search, got %v", files)
+	}
+
+	files, err = findTestFiles(tempDir, "*.yaml", false)
+	if err != nil {
+		t.Fatalf("findTestFiles failed: %v", err)
+	}
+	if len(files) != 1 || filepath.Base(files[0]) != "a_test.yaml" {
+		t.Errorf("Expected only a_test.yaml without recursion, got %v", files)
+	}
+
+	single := filepath.Join(tempDir, "user", "user_test.yaml")
+	files, err = findTestFiles(single, "*.json", true)
+	if err != nil {
+		t.Fatalf("findTestFiles failed for single file: %v", err)
+	}
+	if len(files) != 1 || files[0] != single {
+		t.Errorf("Expected single file to be returned as-is, got %v", files)
+	}
+
+	if _, err := findTestFiles(filepath.Join(tempDir, "missing"), "*.yaml", true); err == nil {
+		t.Error("Expected error for missing config path")
+	}
+}
+
+func TestRunCoverageUsesSuiteSpec(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "spec.yaml"), []byte(`
+openapi: 3.0.0
+info: { title: Ping, version: "1" }
+paths:
+  /ping:
+    get:
+      responses:
+        "200": { description: ok }
+        "404": { description: missing }
+`), 0644)
+	configPath := filepath.Join(tempDir, "ping.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "Ping"
+  base_url: "`+server.URL+`"
+  openapi: spec.yaml
+scenarios:
+  - name: "Ping"
+    testcases:
+      - name: "Ping"
+        request: { method: GET, path: /ping }
+        expect: { status_code: 200 }
+`), 0644)
+
+	// 操作覆盖率 100%，响应码覆盖率 50%
+	if code := run([]string{"-config", configPath, "-coverage-min", "50"}); code != 0 {
+		t.Errorf("Expected coverage from the suite's openapi to pass, got exit code %d", code)
+	}
+	if code := run([]string{"-config", configPath, "-coverage-min", "80"}); code != 1 {
+		t.Errorf("Expected response code coverage below the threshold to fail, got exit code %d", code)
+	}
+}
@@ -0,0 +1,113 @@
+package apitest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+)
+
+// 套件 cookie_jar 的取值：Cookie 在整个套件中共享，或每个场景使用新的 Cookie
+const (
+	CookieJarNone     = "none"
+	CookieJarSuite    = "suite"
+	CookieJarScenario = "scenario"
+)
+
+// cookieSessions 按会话名称保存的 Cookie Jar，空名称为默认会话
+type cookieSessions struct {
+	mu   sync.Mutex
+	jars map[string]*cookiejar.Jar
+}
+
+// newCookieSessions 创建空的会话集合
+func newCookieSessions() *cookieSessions {
+	return &cookieSessions{jars: make(map[string]*cookiejar.Jar)}
+}
+
+// jar 返回会话的 Jar，不存在时创建
+func (s *cookieSessions) jar(name string) http.CookieJar {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jar, ok := s.jars[name]
+	if !ok {
+		jar, _ = cookiejar.New(nil)
+		s.jars[name] = jar
+	}
+	return sessionJar{jar: jar}
+}
+
+// reset 清空会话的 Cookie
+func (s *cookieSessions) reset(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jars, name)
+}
+
+// sessionJar 包装 cookiejar.Jar，base_url 为空（进程内运行）时按 http://localhost 保存 Cookie
+type sessionJar struct {
+	jar *cookiejar.Jar
+}
+
+// SetCookies 实现 http.CookieJar
+func (j sessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(jarURL(u), cookies)
+}
+
+// Cookies 实现 http.CookieJar
+func (j sessionJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(jarURL(u))
+}
+
+// jarURL 为没有协议或主机的 URL 补全 http://localhost
+func jarURL(u *url.URL) *url.URL {
+	if u.Host != "" && u.Scheme != "" {
+		return u
+	}
+	c := *u
+	if c.Scheme == "" {
+		c.Scheme = "http"
+	}
+	if c.Host == "" {
+		c.Host = "localhost"
+	}
+	return &c
+}
+
+// SetCookieJar 设置 Cookie 的共享范围：suite、scenario 或 none（只有指定了 session 的用例使用 Cookie）
+func (r *TestRunner) SetCookieJar(mode string) error {
+	switch mode {
+	case "", CookieJarNone, CookieJarSuite, CookieJarScenario:
+		r.cookieJar = mode
+		return nil
+	}
+	return fmt.Errorf("invalid cookie_jar '%s': expected suite, scenario or none", mode)
+}
+
+// Cookies 返回会话中发往 rawURL 的 Cookie，空名称为默认会话
+func (r *TestRunner) Cookies(session, rawURL string) []*http.Cookie {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	return r.sessions.jar(session).Cookies(u)
+}
+
+// clientFor 返回会话使用的 HTTP 客户端
+// 默认会话只在开启 cookie_jar 时保存 Cookie，命名会话总是保存 Cookie
+func (r *TestRunner) clientFor(session string) *http.Client {
+	if session == "" && (r.cookieJar == "" || r.cookieJar == CookieJarNone) {
+		return r.client
+	}
+	client := *r.client
+	client.Jar = r.sessions.jar(session)
+	return &client
+}
+
+// startScenarioSessions cookie_jar 为 scenario 时为新场景清空所有会话
+func (r *TestRunner) startScenarioSessions() {
+	if r.cookieJar == CookieJarScenario {
+		r.sessions = newCookieSessions()
+	}
+}
@@ -0,0 +1,102 @@
+package apitest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// sessionHandler 登录后通过 Cookie 识别用户
+var sessionHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/login":
+		var body struct{ User string }
+		json.NewDecoder(r.Body).Decode(&body)
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: body.User, Path: "/"})
+		http.Redirect(w, r, "/me", http.StatusFound)
+	case "/logout":
+		http.SetCookie(w, &http.Cookie{Name: "session", Path: "/", MaxAge: -1})
+		w.WriteHeader(http.StatusNoContent)
+	case "/me":
+		c, err := r.Cookie("session")
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"user": c.Value})
+	}
+})
+
+func TestTestRunnerCookieSessions(t *testing.T) {
+	me := func(name, session string, status int, user string) *CaseBuilder {
+		c := NewCase(name).Get("/me").Session(session).ExpectStatus(status)
+		if user != "" {
+			c.Assert("user", "equals", user)
+		}
+		return c
+	}
+	run := func(suite *TestSuite) []TestResult {
+		t.Helper()
+		runner, err := NewTestRunnerFromSuite(suite, nil, &MockCleanupHandler{})
+		if err != nil {
+			t.Fatalf("NewTestRunnerFromSuite failed: %v", err)
+		}
+		runner.SetOutput(io.Discard)
+		runner.SetHandler(sessionHandler)
+		if err := runner.Run(context.Background()); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if cookies := runner.Cookies("bob", "/me"); len(cookies) != 1 || cookies[0].Value != "bob" {
+			t.Errorf("Expected bob's session cookie, got %v", cookies)
+		}
+		return runner.GetResults()
+	}
+	check := func(results []TestResult) {
+		t.Helper()
+		for _, result := range results {
+			if !result.Passed {
+				t.Errorf("Expected %s / %s to pass, got %s", result.Scenario, result.Name, result.Error)
+			}
+		}
+	}
+
+	check(run(NewSuite("Suite Jar").CookieJar(CookieJarSuite).
+		Scenario(NewScenario("Two Users").Case(
+			NewCase("Alice Logs In").Post("/login", map[string]any{"user": "alice"}).ExpectStatus(200).Assert("user", "equals", "alice"),
+			NewCase("Bob Logs In").Post("/login", map[string]any{"user": "bob"}).Session("bob").ExpectStatus(200),
+			me("Alice Is Still Alice", "", 200, "alice"),
+			me("Bob Is Bob", "bob", 200, "bob"),
+			me("Carol Has No Cookies", "carol", 401, ""),
+		)).
+		Scenario(NewScenario("Shared Across Scenarios").Case(
+			me("Alice Carries Over", "", 200, "alice"),
+			NewCase("Alice Logs Out").Post("/logout", nil).ExpectStatus(204),
+			me("Alice Logged Out", "", 401, ""),
+			me("Bob Reset", "bob", 401, "").ResetCookies(),
+			NewCase("Bob Logs In Again").Post("/login", map[string]any{"user": "bob"}).Session("bob").ExpectStatus(200),
+		)).Build()))
+
+	check(run(NewSuite("Scenario Jar").CookieJar(CookieJarScenario).
+		Scenario(NewScenario("Login").Case(
+			NewCase("Alice Logs In").Post("/login", map[string]any{"user": "alice"}).ExpectStatus(200),
+			me("Alice Is Alice", "", 200, "alice"),
+		)).
+		Scenario(NewScenario("Fresh Cookies").Case(
+			me("Alice Forgotten", "", 401, ""),
+			NewCase("Bob Logs In").Post("/login", map[string]any{"user": "bob"}).Session("bob").ExpectStatus(200),
+		)).Build()))
+
+	check(run(NewSuite("No Jar").
+		Scenario(NewScenario("Only Named Sessions").Case(
+			NewCase("Default Login").Post("/login", map[string]any{"user": "alice"}).ExpectStatus(401),
+			NewCase("Bob Logs In").Post("/login", map[string]any{"user": "bob"}).Session("bob").ExpectStatus(200),
+			me("Bob Is Bob", "bob", 200, "bob"),
+		)).Build()))
+
+	if _, err := NewTestRunnerFromSuite(NewSuite("Invalid").CookieJar("global").Build(), nil, nil); err == nil {
+		t.Error("Expected invalid cookie_jar to fail")
+	}
+}
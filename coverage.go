@@ -0,0 +1,191 @@
+package apitest
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// CoverageReport 测试结果对 OpenAPI 规范的覆盖情况
+type CoverageReport struct {
+	Operations []OperationCoverage `json:"operations"`
+	Unmatched  []string            `json:"unmatched,omitempty"` // 未匹配到任何操作的请求，例如 "GET /internal/ping"
+}
+
+// OperationCoverage 单个操作的覆盖情况
+type OperationCoverage struct {
+	Method      string             `json:"method"`
+	Path        string             `json:"path"`
+	OperationID string             `json:"operation_id,omitempty"`
+	Calls       int                `json:"calls"`                // 匹配到该操作的请求数
+	Responses   []ResponseCoverage `json:"responses"`            // 声明的响应码及命中次数
+	Undeclared  []int              `json:"undeclared,omitempty"` // 命中但未声明的状态码
+}
+
+// ResponseCoverage 单个声明响应码的命中次数
+type ResponseCoverage struct {
+	Code string `json:"code"` // 例如 "200"、"4XX"、"default"
+	Hits int    `json:"hits"`
+}
+
+// Covered 操作是否被调用过
+func (c OperationCoverage) Covered() bool {
+	return c.Calls > 0
+}
+
+// Coverage 计算运行器的测试结果对 spec 的覆盖率，spec 为 nil 时使用运行器配置的规范
+func (r *TestRunner) Coverage(spec *OpenAPISpec) (*CoverageReport, error) {
+	if spec == nil {
+		spec = r.openapi
+	}
+	if spec == nil {
+		return nil, fmt.Errorf("no openapi spec configured")
+	}
+	return ComputeCoverage(spec, r), nil
+}
+
+// ComputeCoverage 汇总多个运行器的测试结果，按请求的方法和路径匹配操作，按 Response.StatusCode 统计命中的响应码
+func ComputeCoverage(spec *OpenAPISpec, runners ...*TestRunner) *CoverageReport {
+	report := &CoverageReport{}
+	index := make(map[*OpenAPIOperation]int)
+
+	ops := append([]*OpenAPIOperation(nil), spec.Operations...)
+	sort.SliceStable(ops, func(i, j int) bool {
+		if ops[i].Path != ops[j].Path {
+			return ops[i].Path < ops[j].Path
+		}
+		return ops[i].Method < ops[j].Method
+	})
+	for _, op := range ops {
+		coverage := OperationCoverage{Method: op.Method, Path: op.Path, OperationID: op.OperationID}
+		for _, code := range op.ResponseCodes() {
+			coverage.Responses = append(coverage.Responses, ResponseCoverage{Code: code})
+		}
+		index[op] = len(report.Operations)
+		report.Operations = append(report.Operations, coverage)
+	}
+
+	unmatched := make(map[string]bool)
+	for _, r := range runners {
+		for _, result := range r.GetResults() {
+			if result.Request == nil {
+				continue
+			}
+			op, _, err := r.matchOperation(spec, result.Request.Method, result.Request.URL)
+			if err != nil {
+				if key := result.Request.Method + " " + result.Request.URL; !unmatched[key] {
+					unmatched[key] = true
+					report.Unmatched = append(report.Unmatched, key)
+				}
+				continue
+			}
+
+			coverage := &report.Operations[index[op]]
+			coverage.Calls++
+			if result.Response == nil {
+				continue
+			}
+			status := result.Response.StatusCode
+			key := op.ResponseKey(status)
+			if key == "" {
+				coverage.addUndeclared(status)
+				continue
+			}
+			for i := range coverage.Responses {
+				if coverage.Responses[i].Code == key {
+					coverage.Responses[i].Hits++
+				}
+			}
+		}
+	}
+	return report
+}
+
+// addUndeclared 记录未声明的状态码，去重并保持有序
+func (c *OperationCoverage) addUndeclared(status int) {
+	i := sort.SearchInts(c.Undeclared, status)
+	if i < len(c.Undeclared) && c.Undeclared[i] == status {
+		return
+	}
+	c.Undeclared = append(c.Undeclared, 0)
+	copy(c.Undeclared[i+1:], c.Undeclared[i:])
+	c.Undeclared[i] = status
+}
+
+// OperationPercent 被调用过的操作占比（0-100），规范没有操作时返回 100
+func (c *CoverageReport) OperationPercent() float64 {
+	covered := 0
+	for _, op := range c.Operations {
+		if op.Covered() {
+			covered++
+		}
+	}
+	return percentOf(covered, len(c.Operations))
+}
+
+// ResponsePercent 命中过的声明响应码占比（0-100），规范没有声明响应码时返回 100
+func (c *CoverageReport) ResponsePercent() float64 {
+	hit, total := 0, 0
+	for _, op := range c.Operations {
+		for _, resp := range op.Responses {
+			total++
+			if resp.Hits > 0 {
+				hit++
+			}
+		}
+	}
+	return percentOf(hit, total)
+}
+
+// percentOf 计算百分比，total 为 0 时返回 100
+func percentOf(n, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+// WriteCoverage 以文本格式写入覆盖率报告
+func WriteCoverage(w io.Writer, report *CoverageReport) error {
+	covered := 0
+	for _, op := range report.Operations {
+		if op.Covered() {
+			covered++
+		}
+	}
+
+	fmt.Fprintf(w, "\n📐 OpenAPI Coverage\n\n")
+	for _, op := range report.Operations {
+		mark := "✗"
+		if op.Covered() {
+			mark = "✓"
+		}
+		fmt.Fprintf(w, "  %s %-7s %s", mark, op.Method, op.Path)
+		if op.OperationID != "" {
+			fmt.Fprintf(w, " (%s)", op.OperationID)
+		}
+		fmt.Fprintf(w, " - %d call(s)\n", op.Calls)
+
+		for _, resp := range op.Responses {
+			mark := "✗"
+			if resp.Hits > 0 {
+				mark = "✓"
+			}
+			fmt.Fprintf(w, "      %s %s (%d)\n", mark, resp.Code, resp.Hits)
+		}
+		for _, status := range op.Undeclared {
+			fmt.Fprintf(w, "      ⚠️  %d undeclared\n", status)
+		}
+	}
+
+	if len(report.Unmatched) > 0 {
+		fmt.Fprintf(w, "\n  ⚠️  Requests not in spec:\n")
+		for _, req := range report.Unmatched {
+			fmt.Fprintf(w, "      %s\n", req)
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\n📈 Operations: %d/%d (%.2f%%), Response Codes: %.2f%%\n",
+		covered, len(report.Operations), report.OperationPercent(), report.ResponsePercent())
+	return err
+}
@@ -0,0 +1,104 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestComputeCoverage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/users/1":
+			io.WriteString(w, `{"id": 1, "name": "John"}`)
+		case "/api/users/2":
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message": "not found"}`)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	spec, err := ParseOpenAPI([]byte(testOpenAPISpec))
+	if err != nil {
+		t.Fatalf("ParseOpenAPI failed: %v", err)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "coverage.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "Coverage Suite"
+  base_url: "`+server.URL+`/api"
+scenarios:
+  - name: "Users"
+    testcases:
+      - name: "Found"
+        request: { method: "GET", path: "/users/1" }
+      - name: "Not Found"
+        request: { method: "GET", path: "/users/2" }
+      - name: "Server Error"
+        request: { method: "GET", path: "/users/me" }
+      - name: "Unknown"
+        request: { method: "GET", path: "/ping" }
+`), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("Failed to create TestRunner: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("TestRunner.Run failed: %v", err)
+	}
+
+	if _, err := runner.Coverage(nil); err == nil {
+		t.Error("Expected Coverage to fail without a spec")
+	}
+	report, err := runner.Coverage(spec)
+	if err != nil {
+		t.Fatalf("Coverage failed: %v", err)
+	}
+
+	byName := make(map[string]OperationCoverage)
+	for _, op := range report.Operations {
+		byName[op.OperationID] = op
+	}
+	if get := byName["getUser"]; get.Calls != 2 || get.Responses[0].Hits != 1 || get.Responses[1].Hits != 1 {
+		t.Errorf("Unexpected getUser coverage: %+v", get)
+	}
+	if me := byName["getMe"]; me.Calls != 1 || me.Responses[0].Hits != 0 || len(me.Undeclared) != 1 || me.Undeclared[0] != 500 {
+		t.Errorf("Unexpected getMe coverage: %+v", me)
+	}
+	if create := byName["createUser"]; create.Covered() {
+		t.Errorf("createUser should not be covered: %+v", create)
+	}
+	if len(report.Unmatched) != 1 || !strings.HasSuffix(report.Unmatched[0], "/api/ping") {
+		t.Errorf("Expected /api/ping to be unmatched, got %v", report.Unmatched)
+	}
+
+	// 2/3 个操作，4 个声明响应码中命中 2 个
+	if p := report.OperationPercent(); p < 66.6 || p > 66.7 {
+		t.Errorf("Expected operation coverage 66.67%%, got %.2f", p)
+	}
+	if p := report.ResponsePercent(); p != 50 {
+		t.Errorf("Expected response coverage 50%%, got %.2f", p)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCoverage(&buf, report); err != nil {
+		t.Fatalf("WriteCoverage failed: %v", err)
+	}
+	for _, want := range []string{"✓ GET     /users/{id} (getUser) - 2 call(s)", "✗ POST    /users (createUser)", "500 undeclared", "Operations: 2/3 (66.67%)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected coverage output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
@@ -1,4 +1,4 @@
-// Package apitest 提供自动生成go api测试用例的功能
+// Package apitest 提供基于 YAML 的 API 集成测试，并支持从 OpenAPI 规范自动生成go api测试用例
 package apitest
 
 import (
@@ -7,9 +7,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/yannick2025-tech/gwc-db"
@@ -22,25 +30,32 @@ import (
 // TestSuite 测试套件配置
 type TestSuite struct {
 	Suite     SuiteConfig `yaml:"suite"`
-	Variables Variables   `yaml:"variables"`
-	Scenarios []Scenario  `yaml:"scenarios"`
+	Variables Variables   `yaml:"variables,omitempty"`
+	Scenarios []Scenario  `yaml:"scenarios,omitempty"`
+	Mocks     []Mock      `yaml:"mocks,omitempty"` // apitest mock 使用的模拟接口
 }
 
 // SuiteConfig 套件配置
 type SuiteConfig struct {
-	Name     string        `yaml:"name"`
-	BaseURL  string        `yaml:"base_url"`
-	Setup    []SetupAction `yaml:"setup"`
-	Teardown []SetupAction `yaml:"teardown"`
+	Name          string        `yaml:"name"`
+	BaseURL       string        `yaml:"base_url,omitempty"`
+	Setup         []SetupAction `yaml:"setup,omitempty"`
+	Teardown      []SetupAction `yaml:"teardown,omitempty"`
+	RedactHeaders []string      `yaml:"redact_headers,omitempty"`  // 导出前需要脱敏的请求头/响应头，追加到默认列表
+	OpenAPI       string        `yaml:"openapi,omitempty"`         // OpenAPI 3 规范文件路径（相对于套件文件所在目录）
+	OpenAPIMode   string        `yaml:"openapi_mode,omitempty"`    // strict（默认，违反规范记为失败）或 warn（只记录警告）
+	Soft          bool          `yaml:"soft_assertions,omitempty"` // 所有用例执行全部校验项后再报告失败，见 ExpectConfig.Soft
+	CookieJar     string        `yaml:"cookie_jar,omitempty"`      // suite（整个套件共享 Cookie）、scenario（每个场景使用新的 Cookie）或 none（默认）
+	Auth          *AuthConfig   `yaml:"auth,omitempty"`            // 所有请求使用的认证，见 AuthConfig
 }
 
 // SetupAction 设置/清理动作
 type SetupAction struct {
-	Type      string         `yaml:"type"`      // cleanup, soft_delete_cleanup, sql, api_call
-	Table     string         `yaml:"table"`     // 表名
-	Condition string         `yaml:"condition"` // WHERE 条件
-	SQL       string         `yaml:"sql"`       // 自定义 SQL
-	Request   *RequestConfig `yaml:"request"`   // API 调用配置
+	Type      string         `yaml:"type,omitempty"`      // cleanup, soft_delete_cleanup, sql, api_call
+	Table     string         `yaml:"table,omitempty"`     // 表名
+	Condition string         `yaml:"condition,omitempty"` // WHERE 条件
+	SQL       string         `yaml:"sql,omitempty"`       // 自定义 SQL
+	Request   *RequestConfig `yaml:"request,omitempty"`   // API 调用配置
 }
 
 // Variables 变量定义
@@ -49,34 +64,46 @@ type Variables map[string]any
 // Scenario 测试场景（业务流程分组）
 type Scenario struct {
 	Name        string     `yaml:"name"`
-	Description string     `yaml:"description"`
-	TestCases   []TestCase `yaml:"testcases"`
+	Description string     `yaml:"description,omitempty"`
+	Parallel    bool       `yaml:"parallel,omitempty"` // 与相邻的 parallel 场景并发执行，使用独立的变量作用域
+	TestCases   []TestCase `yaml:"testcases,omitempty"`
 }
 
 // TestCase 测试用例
 type TestCase struct {
-	Name      string            `yaml:"name"`
-	DependsOn string            `yaml:"depends_on"`
-	Request   RequestConfig     `yaml:"request"`
-	Expect    ExpectConfig      `yaml:"expect"`
-	Save      map[string]string `yaml:"save"`
-	Retry     *RetryConfig      `yaml:"retry"`
+	Name         string            `yaml:"name"`
+	DependsOn    string            `yaml:"depends_on,omitempty"`
+	Request      RequestConfig     `yaml:"request"`
+	Expect       ExpectConfig      `yaml:"expect"`
+	Save         map[string]string `yaml:"save,omitempty"`
+	Retry        *RetryConfig      `yaml:"retry,omitempty"`
+	Tags         []string          `yaml:"tags,omitempty"`          // 用例标签，例如 slow（go test -short 时跳过）
+	Session      string            `yaml:"session,omitempty"`       // 使用的 Cookie 会话，不同会话可以模拟不同用户
+	ResetCookies bool              `yaml:"reset_cookies,omitempty"` // 发送请求前清空会话的 Cookie
+	Auth         *AuthConfig       `yaml:"auth,omitempty"`          // 覆盖套件的认证配置，auth: none 表示不认证
 }
 
 // RequestConfig 请求配置
 type RequestConfig struct {
-	Method  string            `yaml:"method"`
-	Path    string            `yaml:"path"`
-	Headers map[string]string `yaml:"headers"`
-	Body    map[string]any    `yaml:"body"`
-	Query   map[string]string `yaml:"query"`
+	Method      string            `yaml:"method"`
+	Path        string            `yaml:"path"`
+	Headers     map[string]string `yaml:"headers,omitempty"`
+	Body        map[string]any    `yaml:"body,omitempty"` // json、xml 的内容，或 form、multipart 的字段
+	Query       map[string]string `yaml:"query,omitempty"`
+	BodyType    string            `yaml:"body_type,omitempty"`    // json（默认）、form、multipart、raw 或 xml
+	ContentType string            `yaml:"content_type,omitempty"` // 覆盖默认的 Content-Type，未设置 body_type 时用于推断类型
+	Raw         string            `yaml:"raw,omitempty"`          // 原始请求体（替换变量），用于 raw、xml 或 json
+	BodyFile    string            `yaml:"body_file,omitempty"`    // 从文件读取原始请求体（不替换变量），相对于套件文件所在目录
+	Files       map[string]string `yaml:"files,omitempty"`        // multipart 上传的文件：字段名 -> 文件路径（支持变量）
 }
 
 // ExpectConfig 期望配置
 type ExpectConfig struct {
-	StatusCode   int            `yaml:"status_code"`
-	ResponseBody map[string]any `yaml:"response_body"` // 用于校验 code 等字段
-	Assertions   []Assertion    `yaml:"assertions"`
+	StatusCode   int            `yaml:"status_code,omitempty"`
+	ResponseBody map[string]any `yaml:"response_body,omitempty"` // 用于校验 code 等字段
+	Schema       any            `yaml:"schema,omitempty"`        // 响应体的 JSON Schema（draft 2020-12），内联或 JSON/YAML 文件路径
+	Assertions   []Assertion    `yaml:"assertions,omitempty"`
+	Soft         *bool          `yaml:"soft,omitempty"` // 执行全部校验项后再报告失败，未设置时使用套件的 soft_assertions
 }
 
 // Assertion 断言配置
@@ -88,34 +115,73 @@ type Assertion struct {
 
 // RetryConfig 重试配置
 type RetryConfig struct {
-	Times    int `yaml:"times"`
-	Interval int `yaml:"interval"` // 毫秒
+	Times    int `yaml:"times,omitempty"`
+	Interval int `yaml:"interval,omitempty"` // 毫秒
 }
 
 // TestRunner 测试运行器
 type TestRunner struct {
-	suite     *TestSuite
-	client    *http.Client
-	variables Variables
-	results   []TestResult
-	cleanup   CleanupHandler
-	dbAdapter db.DBAdapter // 数据库适配器，用于软删除清理
+	suite       *TestSuite
+	configPath  string // 配置文件路径，用于报告
+	fsys        fs.FS  // 非 nil 时从中读取套件引用的文件
+	client      *http.Client
+	variables   Variables
+	results     []TestResult
+	mu          sync.Mutex // 保护 results
+	cleanup     CleanupHandler
+	dbAdapter   db.DBAdapter    // 数据库适配器，用于软删除清理
+	out         io.Writer       // 运行输出，默认 os.Stdout
+	parallelism int             // 并发场景的最大 worker 数
+	slots       chan struct{}   // 与其他运行器共享的并发令牌，见 ShareParallelism
+	redact      []string        // 导出前脱敏的头名称
+	redactQuery []string        // 导出前脱敏的查询参数名称（in: query 的 api_key）
+	openapi     *OpenAPISpec    // 用于契约校验的 OpenAPI 规范，nil 表示不校验
+	openapiMode string          // OpenAPIModeStrict 或 OpenAPIModeWarn
+	hooks       []Hooks         // 生命周期钩子
+	soft        bool            // 默认是否使用 soft 断言
+	cookieJar   string          // Cookie 共享范围，见 SetCookieJar
+	sessions    *cookieSessions // 按会话名称保存的 Cookie
+	tokens      *tokenCache     // OAuth2 访问令牌缓存
 }
 
 // TestResult 测试结果
 type TestResult struct {
-	Scenario string        `json:"scenario"`
-	Name     string        `json:"name"`
-	Passed   bool          `json:"passed"`
-	Duration time.Duration `json:"duration"`
-	Error    string        `json:"error,omitempty"`
-	Response *ResponseData `json:"response,omitempty"`
+	Scenario   string            `json:"scenario"`
+	Name       string            `json:"name"`
+	Passed     bool              `json:"passed"`
+	Duration   time.Duration     `json:"duration"`
+	Error      string            `json:"error,omitempty"`
+	Request    *RequestData      `json:"request,omitempty"`
+	Response   *ResponseData     `json:"response,omitempty"`
+	Assertions []AssertionResult `json:"assertions,omitempty"`
+	Saved      map[string]any    `json:"saved,omitempty"` // 本用例保存的变量
+	Warnings   []string          `json:"warnings,omitempty"`
+	Failures   []AssertionResult `json:"failures,omitempty"` // 失败的校验项，soft 模式下包含全部失败
+}
+
+// RequestData 实际发出的请求（变量替换之后）
+type RequestData struct {
+	Method   string              `json:"method"`
+	URL      string              `json:"url"` // 包含查询参数的完整 URL
+	Headers  map[string][]string `json:"headers,omitempty"`
+	Body     string              `json:"body,omitempty"` // 编码后的请求体
+	Attempts int                 `json:"attempts"`       // 实际发送次数（含重试）
+}
+
+// AssertionResult 单个校验项（状态码、response_body 字段或断言）的结果
+type AssertionResult struct {
+	Path     string `json:"path"`
+	Operator string `json:"operator"`
+	Expected any    `json:"expected"`
+	Actual   any    `json:"actual"`
+	Passed   bool   `json:"passed"`
+	Error    string `json:"error,omitempty"`
 }
 
 // ResponseData 响应数据
 type ResponseData struct {
 	StatusCode int                 `json:"status_code"`
-	Body       map[string]any      `json:"body"`
+	Body       any                 `json:"body"` // JSON 对象、数组或标量，文本响应为字符串，二进制响应为 null
 	Headers    map[string][]string `json:"headers"`
 }
 
@@ -124,61 +190,277 @@ type CleanupHandler interface {
 	Execute(ctx context.Context, action SetupAction) error
 }
 
-// NewTestRunner 创建测试运行器
-func NewTestRunner(configPath string, dbAdapter db.DBAdapter, cleanup CleanupHandler) (*TestRunner, error) {
-	data, err := os.ReadFile(configPath)
+// LoadSuite 从 YAML 文件加载测试套件
+func LoadSuite(path string) (*TestSuite, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
+	return ParseSuite(data)
+}
 
+// LoadSuiteFS 从文件系统（例如 embed.FS）加载测试套件
+func LoadSuiteFS(fsys fs.FS, name string) (*TestSuite, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return ParseSuite(data)
+}
+
+// ReadSuite 从 io.Reader 读取 YAML 格式的测试套件
+func ReadSuite(r io.Reader) (*TestSuite, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return ParseSuite(data)
+}
+
+// ParseSuite 解析 YAML 格式的测试套件
+func ParseSuite(data []byte) (*TestSuite, error) {
 	var suite TestSuite
 	if err := yaml.Unmarshal(data, &suite); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
+	if suite.Variables == nil {
+		suite.Variables = Variables{}
+	}
+	suite.normalize()
+	return &suite, nil
+}
+
+// normalize 将套件中 YAML 解码出的 map[any]any 转换为 map[string]any
+// 在加载套件时执行一次，场景和用例使用新的切片，运行期间只读取这些值
+func (s *TestSuite) normalize() {
+	for k, v := range s.Variables {
+		s.Variables[k] = normalizeYAML(v)
+	}
+	s.Suite.Setup = normalizeActions(s.Suite.Setup)
+	s.Suite.Teardown = normalizeActions(s.Suite.Teardown)
+
+	scenarios := make([]Scenario, len(s.Scenarios))
+	for i, scenario := range s.Scenarios {
+		cases := make([]TestCase, len(scenario.TestCases))
+		for j, tc := range scenario.TestCases {
+			tc.Request.Body = normalizeMap(tc.Request.Body)
+			tc.Expect.ResponseBody = normalizeMap(tc.Expect.ResponseBody)
+			tc.Expect.Schema = normalizeYAML(tc.Expect.Schema)
+			tc.Expect.Assertions = append([]Assertion(nil), tc.Expect.Assertions...)
+			for k := range tc.Expect.Assertions {
+				tc.Expect.Assertions[k].Value = normalizeYAML(tc.Expect.Assertions[k].Value)
+			}
+			cases[j] = tc
+		}
+		scenario.TestCases = cases
+		scenarios[i] = scenario
+	}
+	if s.Scenarios != nil {
+		s.Scenarios = scenarios
+	}
+}
 
-	return &TestRunner{
-		suite:     &suite,
-		client:    &http.Client{Timeout: 30 * time.Second},
-		variables: suite.Variables,
-		cleanup:   cleanup,
-		dbAdapter: dbAdapter,
-	}, nil
+// normalizeActions 返回 setup/teardown 的副本，api_call 的请求体已规范化
+func normalizeActions(actions []SetupAction) []SetupAction {
+	if actions == nil {
+		return nil
+	}
+	out := make([]SetupAction, len(actions))
+	for i, action := range actions {
+		if action.Request != nil {
+			req := *action.Request
+			req.Body = normalizeMap(req.Body)
+			action.Request = &req
+		}
+		out[i] = action
+	}
+	return out
+}
+
+// NewTestRunner 创建测试运行器
+func NewTestRunner(configPath string, dbAdapter db.DBAdapter, cleanup CleanupHandler) (*TestRunner, error) {
+	suite, err := LoadSuite(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return newTestRunner(suite, configPath, nil, dbAdapter, cleanup)
+}
+
+// NewTestRunnerFS 从文件系统（例如 embed.FS）加载套件并创建测试运行器
+// 套件引用的 openapi 和 schema 文件也从 fsys 中读取
+func NewTestRunnerFS(fsys fs.FS, name string, dbAdapter db.DBAdapter, cleanup CleanupHandler) (*TestRunner, error) {
+	suite, err := LoadSuiteFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return newTestRunner(suite, name, fsys, dbAdapter, cleanup)
+}
+
+// NewTestRunnerFromSuite 使用代码构建（例如 NewSuite）或已解析的套件创建测试运行器
+// 套件引用的相对路径文件相对于当前工作目录
+func NewTestRunnerFromSuite(suite *TestSuite, dbAdapter db.DBAdapter, cleanup CleanupHandler) (*TestRunner, error) {
+	if suite.Variables == nil {
+		suite.Variables = Variables{}
+	}
+	return newTestRunner(suite, "", nil, dbAdapter, cleanup)
+}
+
+// newTestRunner 创建测试运行器，configPath 和 fsys 用于解析套件引用的文件
+func newTestRunner(suite *TestSuite, configPath string, fsys fs.FS, dbAdapter db.DBAdapter, cleanup CleanupHandler) (*TestRunner, error) {
+	runner := &TestRunner{
+		suite:       suite,
+		configPath:  configPath,
+		fsys:        fsys,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		variables:   suite.Variables,
+		cleanup:     cleanup,
+		dbAdapter:   dbAdapter,
+		out:         os.Stdout,
+		parallelism: 1,
+		redact:      append(append([]string(nil), DefaultRedactHeaders...), suite.Suite.RedactHeaders...),
+		soft:        suite.Suite.Soft,
+		sessions:    newCookieSessions(),
+		tokens:      newTokenCache(),
+	}
+
+	if err := runner.SetOpenAPIMode(suite.Suite.OpenAPIMode); err != nil {
+		return nil, err
+	}
+	if err := runner.SetCookieJar(suite.Suite.CookieJar); err != nil {
+		return nil, err
+	}
+	if err := runner.validateAuth(); err != nil {
+		return nil, err
+	}
+	if path := suite.Suite.OpenAPI; path != "" {
+		data, err := runner.readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read openapi spec: %w", err)
+		}
+		spec, err := ParseOpenAPI(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		runner.openapi = spec
+	}
+
+	return runner, nil
+}
+
+// readFile 读取套件引用的文件，相对路径相对于套件文件所在目录
+func (r *TestRunner) readFile(name string) ([]byte, error) {
+	if r.fsys != nil {
+		if !path.IsAbs(name) {
+			name = path.Join(path.Dir(r.configPath), name)
+		}
+		return fs.ReadFile(r.fsys, strings.TrimPrefix(name, "/"))
+	}
+	if !filepath.IsAbs(name) && r.configPath != "" {
+		name = filepath.Join(filepath.Dir(r.configPath), name)
+	}
+	return os.ReadFile(name)
+}
+
+// SetOutput 设置运行输出的目标，默认 os.Stdout
+func (r *TestRunner) SetOutput(w io.Writer) {
+	r.out = w
+}
+
+// SetRedactHeaders 设置导出前需要脱敏的头名称（不区分大小写），替换默认列表和配置中的列表
+// 不传参数时关闭脱敏
+func (r *TestRunner) SetRedactHeaders(names ...string) {
+	r.redact = names
+}
+
+// SetOpenAPISpec 设置用于契约校验的 OpenAPI 规范，替换配置中的 openapi，传 nil 时关闭校验
+func (r *TestRunner) SetOpenAPISpec(spec *OpenAPISpec) {
+	r.openapi = spec
+}
+
+// OpenAPISpec 返回当前使用的 OpenAPI 规范，未配置时返回 nil
+func (r *TestRunner) OpenAPISpec() *OpenAPISpec {
+	return r.openapi
+}
+
+// SetOpenAPIMode 设置契约校验模式：strict（默认）或 warn，空字符串按 strict 处理
+func (r *TestRunner) SetOpenAPIMode(mode string) error {
+	switch mode {
+	case "", OpenAPIModeStrict:
+		r.openapiMode = OpenAPIModeStrict
+	case OpenAPIModeWarn:
+		r.openapiMode = OpenAPIModeWarn
+	default:
+		return fmt.Errorf("unknown openapi_mode '%s': expected %s or %s", mode, OpenAPIModeStrict, OpenAPIModeWarn)
+	}
+	return nil
+}
+
+// SetParallelism 设置 parallel 场景的最大并发数，小于 1 时按 1 处理
+func (r *TestRunner) SetParallelism(n int) {
+	if n < 1 {
+		n = 1
+	}
+	r.parallelism = n
+}
+
+// ShareParallelism 使 parallel 场景与其他运行器共享同一个并发上限，slots 的容量即总并发数
+// 调用方在运行期间为该运行器占用一个令牌：并发批次中的一个 worker 使用这个令牌，其余 worker 各自获取空闲令牌后才开始执行场景
+func (r *TestRunner) ShareParallelism(slots chan struct{}) {
+	r.slots = slots
+}
+
+// SetSoftAssertions 设置未配置 expect.soft 的用例是否使用 soft 断言，覆盖配置中的 soft_assertions
+func (r *TestRunner) SetSoftAssertions(soft bool) {
+	r.soft = soft
+}
+
+// SetTransport 设置发送请求使用的 http.RoundTripper，传 nil 时恢复 http.DefaultTransport
+func (r *TestRunner) SetTransport(rt http.RoundTripper) {
+	r.client = &http.Client{Timeout: r.client.Timeout, Transport: rt}
+}
+
+// SetHandler 在进程内将请求交给 handler 处理，不监听端口也不经过网络
+// base_url 只用于拼接请求 URL，可以为空或任意地址，例如 http://localhost/api
+func (r *TestRunner) SetHandler(handler http.Handler) {
+	r.SetTransport(handlerTransport{handler: handler})
 }
 
 // Run 运行所有测试
 func (r *TestRunner) Run(ctx context.Context) error {
-	fmt.Printf("🚀 Running test suite: %s\n", r.suite.Suite.Name)
-	fmt.Printf("📍 Base URL: %s\n\n", r.suite.Suite.BaseURL)
+	fmt.Fprintf(r.out, "🚀 Running test suite: %s\n", r.suite.Suite.Name)
+	fmt.Fprintf(r.out, "📍 Base URL: %s\n\n", r.suite.Suite.BaseURL)
+
+	if err := r.beforeSuite(ctx); err != nil {
+		return err
+	}
 
 	// 执行 setup
 	if err := r.executeSetup(ctx); err != nil {
 		return fmt.Errorf("setup failed: %w", err)
 	}
 
-	// 执行测试场景
-	for _, scenario := range r.suite.Scenarios {
-		fmt.Printf("📦 Scenario: %s\n", scenario.Name)
-		if scenario.Description != "" {
-			fmt.Printf("   %s\n", scenario.Description)
+	// 执行测试场景，相邻的 parallel 场景作为一批并发执行
+	scenarios := r.suite.Scenarios
+	for i := 0; i < len(scenarios); {
+		if !scenarios[i].Parallel {
+			r.runScenario(ctx, scenarios[i])
+			i++
+			continue
 		}
 
-		for _, tc := range scenario.TestCases {
-			result := r.runTestCase(ctx, scenario.Name, tc)
-			r.results = append(r.results, result)
-
-			if result.Passed {
-				fmt.Printf("   ✓ %s (%.2fs)\n", result.Name, result.Duration.Seconds())
-			} else {
-				fmt.Printf("   ✗ %s (%.2fs): %s\n", result.Name, result.Duration.Seconds(), result.Error)
-			}
+		j := i
+		for j < len(scenarios) && scenarios[j].Parallel {
+			j++
 		}
-		fmt.Println()
+		r.runParallelScenarios(ctx, scenarios[i:j])
+		i = j
 	}
 
 	// 执行 teardown
 	if err := r.executeTeardown(ctx); err != nil {
-		fmt.Printf("⚠️  Warning: teardown failed: %v\n", err)
+		fmt.Fprintf(r.out, "⚠️  Warning: teardown failed: %v\n", err)
 	}
+	r.afterSuite(ctx)
 
 	// 打印摘要
 	r.printSummary()
@@ -186,8 +468,140 @@ func (r *TestRunner) Run(ctx context.Context) error {
 	return nil
 }
 
-// runTestCase 运行单个测试用例
+// runScenario 顺序运行场景中的所有测试用例
+func (r *TestRunner) runScenario(ctx context.Context, scenario Scenario) {
+	fmt.Fprintf(r.out, "📦 Scenario: %s\n", scenario.Name)
+	if scenario.Description != "" {
+		fmt.Fprintf(r.out, "   %s\n", scenario.Description)
+	}
+
+	r.startScenarioSessions()
+	hookErr := r.beforeScenario(ctx, scenario)
+	results := make([]TestResult, 0, len(scenario.TestCases))
+	for _, tc := range scenario.TestCases {
+		var result TestResult
+		if hookErr != nil {
+			result = TestResult{Scenario: scenario.Name, Name: tc.Name, Error: hookErr.Error()}
+		} else {
+			result = r.runTestCase(ctx, scenario.Name, tc)
+		}
+		r.addResult(result)
+		results = append(results, result)
+
+		if result.Passed {
+			fmt.Fprintf(r.out, "   ✓ %s (%.2fs)\n", result.Name, result.Duration.Seconds())
+		} else if len(result.Failures) > 1 {
+			fmt.Fprintf(r.out, "   ✗ %s (%.2fs): %d checks failed\n", result.Name, result.Duration.Seconds(), len(result.Failures))
+			for _, f := range result.Failures {
+				fmt.Fprintf(r.out, "     • %s\n", f.Error)
+			}
+		} else {
+			fmt.Fprintf(r.out, "   ✗ %s (%.2fs): %s\n", result.Name, result.Duration.Seconds(), result.Error)
+		}
+		for _, w := range result.Warnings {
+			fmt.Fprintf(r.out, "     ⚠️  %s\n", w)
+		}
+	}
+	r.afterScenario(ctx, scenario, results)
+	fmt.Fprintln(r.out)
+}
+
+// runParallelScenarios 使用 worker 池并发运行一批场景
+// 每个场景在独立的 fork 上运行，输出和结果按场景声明顺序合并，保证结果确定
+func (r *TestRunner) runParallelScenarios(ctx context.Context, scenarios []Scenario) {
+	forks := make([]*TestRunner, len(scenarios))
+	for i := range scenarios {
+		forks[i] = r.fork()
+	}
+	offset := len(r.GetResults())
+
+	workers := min(r.parallelism, len(scenarios))
+	jobs := make(chan int)
+	dispatched := make(chan struct{}) // 所有场景都已分配，等待令牌的 worker 不再需要执行
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(shared bool) {
+			defer wg.Done()
+			if shared {
+				select {
+				case r.slots <- struct{}{}:
+					defer func() { <-r.slots }()
+				case <-dispatched:
+					return
+				}
+			}
+			for i := range jobs {
+				forks[i].runScenario(ctx, scenarios[i])
+			}
+		}(w > 0 && r.slots != nil)
+	}
+	for i := range scenarios {
+		jobs <- i
+	}
+	close(jobs)
+	close(dispatched)
+	wg.Wait()
+
+	for _, f := range forks {
+		r.out.Write(f.out.(*bytes.Buffer).Bytes())
+		r.addResult(f.GetResults()[offset:]...)
+	}
+}
+
+// fork 创建一个共享 client 和套件配置的子运行器
+// 子运行器拥有变量的独立副本和当前结果的快照（用于 depends_on），输出写入内存缓冲
+func (r *TestRunner) fork() *TestRunner {
+	variables := make(Variables, len(r.variables))
+	for k, v := range r.variables {
+		variables[k] = v
+	}
+
+	return &TestRunner{
+		suite:       r.suite,
+		configPath:  r.configPath,
+		fsys:        r.fsys,
+		client:      r.client,
+		variables:   variables,
+		results:     r.GetResults(),
+		cleanup:     r.cleanup,
+		dbAdapter:   r.dbAdapter,
+		out:         &bytes.Buffer{},
+		parallelism: r.parallelism,
+		slots:       r.slots,
+		redact:      r.redact,
+		redactQuery: r.redactQuery,
+		openapi:     r.openapi,
+		openapiMode: r.openapiMode,
+		hooks:       r.hooks,
+		soft:        r.soft,
+		cookieJar:   r.cookieJar,
+		sessions:    r.sessions,
+		tokens:      r.tokens,
+	}
+}
+
+// addResult 追加测试结果
+func (r *TestRunner) addResult(results ...TestResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, results...)
+}
+
+// runTestCase 运行单个测试用例，完成后调用 AfterResponse 钩子
 func (r *TestRunner) runTestCase(ctx context.Context, scenario string, tc TestCase) TestResult {
+	result := r.executeTestCase(ctx, scenario, tc)
+	r.afterResponse(ctx, &result)
+	for _, outcome := range result.Assertions {
+		if !outcome.Passed {
+			result.Failures = append(result.Failures, outcome)
+		}
+	}
+	return result
+}
+
+// executeTestCase 发送请求并校验响应
+func (r *TestRunner) executeTestCase(ctx context.Context, scenario string, tc TestCase) TestResult {
 	start := time.Now()
 	result := TestResult{
 		Scenario: scenario,
@@ -203,9 +617,10 @@ func (r *TestRunner) runTestCase(ctx context.Context, scenario string, tc TestCa
 	}
 
 	// 构建请求
-	req, err := r.buildRequest(tc.Request)
+	auth := r.authFor(tc)
+	req, err := r.newRequest(ctx, tc.Request, auth)
 	if err != nil {
-		result.Error = fmt.Sprintf("build request failed: %v", err)
+		result.Error = err.Error()
 		result.Duration = time.Since(start)
 		return result
 	}
@@ -216,12 +631,26 @@ func (r *TestRunner) runTestCase(ctx context.Context, scenario string, tc TestCa
 	retryInterval := 0
 
 	if tc.Retry != nil {
-		retryTimes = tc.Retry.Times
+		retryTimes = max(tc.Retry.Times, 1)
 		retryInterval = tc.Retry.Interval
 	}
 
+	if tc.ResetCookies {
+		r.sessions.reset(tc.Session)
+	}
+	client := r.clientFor(tc.Session)
+
+	attempts := 0
 	for i := 0; i < retryTimes; i++ {
-		resp, err = r.client.Do(req)
+		attempts++
+		if err = r.beforeRequest(ctx, req); err != nil {
+			result.Request = captureRequest(req)
+			result.Request.Attempts = attempts
+			result.Error = err.Error()
+			result.Duration = time.Since(start)
+			return result
+		}
+		resp, err = client.Do(req)
 		if err == nil {
 			break
 		}
@@ -229,10 +658,35 @@ func (r *TestRunner) runTestCase(ctx context.Context, scenario string, tc TestCa
 		if i < retryTimes-1 {
 			time.Sleep(time.Duration(retryInterval) * time.Millisecond)
 			// 重新构建请求（因为 Body 已经被读取）
-			req, _ = r.buildRequest(tc.Request)
+			if next, err := r.newRequest(ctx, tc.Request, auth); err == nil {
+				req = next
+			}
+		}
+	}
+
+	// OAuth2 令牌被拒绝（例如服务端提前吊销）时丢弃缓存，重新获取令牌后再发送一次
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && auth != nil && auth.Type == AuthOAuth2 && !hasHeader(tc.Request.Headers, "Authorization") {
+		resp.Body.Close()
+		r.invalidateToken(auth)
+		if req, err = r.newRequest(ctx, tc.Request, auth); err != nil {
+			result.Error = err.Error()
+			result.Duration = time.Since(start)
+			return result
+		}
+		attempts++
+		if err = r.beforeRequest(ctx, req); err != nil {
+			result.Request = captureRequest(req)
+			result.Request.Attempts = attempts
+			result.Error = err.Error()
+			result.Duration = time.Since(start)
+			return result
 		}
+		resp, err = client.Do(req)
 	}
 
+	result.Request = captureRequest(req)
+	result.Request.Attempts = attempts
+
 	if err != nil {
 		result.Error = fmt.Sprintf("request failed: %v", err)
 		result.Duration = time.Since(start)
@@ -248,33 +702,57 @@ func (r *TestRunner) runTestCase(ctx context.Context, scenario string, tc TestCa
 		return result
 	}
 
-	// 🔧 修改点1: 使用 safejson 解析响应，避免大整数精度丢失
-	var respData map[string]any
-	if len(body) > 0 {
-		respData, err = safejson.SafeUnmarshalToMap(body)
-		if err != nil {
-			result.Error = fmt.Sprintf("parse response failed: %v", err)
-			result.Duration = time.Since(start)
-			return result
-		}
+	// 🔧 修改点1: 按 Content-Type 解析响应，JSON 使用 safejson 避免大整数精度丢失
+	parsed, err := parseResponse(resp.Header, body)
+	if err != nil {
+		result.Error = fmt.Sprintf("parse response failed: %v", err)
+		result.Duration = time.Since(start)
+		return result
 	}
+	respData := parsed.data
 
 	result.Response = &ResponseData{
 		StatusCode: resp.StatusCode,
-		Body:       respData,
+		Body:       parsed.body(),
 		Headers:    resp.Header,
 	}
 
 	// 验证期望
-	if err := r.validateExpectation(tc.Expect, resp.StatusCode, respData); err != nil {
+	soft := r.soft
+	if tc.Expect.Soft != nil {
+		soft = *tc.Expect.Soft
+	}
+	outcomes, err := r.validateExpectation(tc.Expect, soft, resp.StatusCode, parsed)
+	result.Assertions = outcomes
+	if err != nil {
 		result.Error = err.Error()
 		result.Duration = time.Since(start)
 		return result
 	}
 
+	// OpenAPI 契约校验
+	if r.openapi != nil {
+		violations := r.validateOpenAPI(result.Request, resp, respData, len(body) > 0)
+		if r.openapiMode == OpenAPIModeWarn {
+			result.Warnings = violations
+		} else if len(violations) > 0 {
+			for _, v := range violations {
+				result.Assertions = append(result.Assertions, AssertionResult{Path: "openapi", Operator: "openapi", Passed: false, Error: v})
+			}
+			result.Error = fmt.Sprintf("openapi contract violated: %s", strings.Join(violations, "; "))
+			result.Duration = time.Since(start)
+			return result
+		}
+	}
+
 	// 保存变量
 	if tc.Save != nil {
-		r.saveVariables(tc.Save, respData)
+		result.Saved, err = r.saveVariables(tc.Save, parsed)
+		if err != nil {
+			result.Error = fmt.Sprintf("save failed: %v", err)
+			result.Duration = time.Since(start)
+			return result
+		}
 	}
 
 	result.Passed = true
@@ -282,27 +760,51 @@ func (r *TestRunner) runTestCase(ctx context.Context, scenario string, tc TestCa
 	return result
 }
 
+// captureRequest 记录请求的方法、URL、请求头和请求体
+// 请求体通过 GetBody 读取副本，不影响已发送的请求
+func captureRequest(req *http.Request) *RequestData {
+	data := &RequestData{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Headers: req.Header.Clone(),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			b, _ := io.ReadAll(body)
+			body.Close()
+			data.Body = string(b)
+		}
+	}
+	return data
+}
+
+// newRequest 构建请求并添加认证信息
+func (r *TestRunner) newRequest(ctx context.Context, cfg RequestConfig, auth *AuthConfig) (*http.Request, error) {
+	req, err := r.buildRequest(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("build request failed: %w", err)
+	}
+	if err := r.applyAuth(ctx, req, auth); err != nil {
+		return nil, fmt.Errorf("authentication failed: %w", err)
+	}
+	return req, nil
+}
+
 // buildRequest 构建 HTTP 请求
 func (r *TestRunner) buildRequest(cfg RequestConfig) (*http.Request, error) {
 	// 替换路径中的变量
 	path := r.replaceVariables(cfg.Path)
 	url := r.suite.Suite.BaseURL + path
 
-	// 构建请求体
+	// 构建请求体，按 body_type 编码
 	var body io.Reader
-	if cfg.Body != nil {
-		bodyData := r.replaceMapVariables(cfg.Body)
-
-		// 使用自定义 JSON 编码器，确保 int64 不会被序列化为科学计数法
-		var buf bytes.Buffer
-		encoder := json.NewEncoder(&buf)
-		encoder.SetEscapeHTML(false)
-
-		if err := encoder.Encode(bodyData); err != nil {
-			return nil, fmt.Errorf("failed to encode request body: %w", err)
+	var contentType string
+	if cfg.hasBody() {
+		buf, defaultType, err := r.buildBody(cfg)
+		if err != nil {
+			return nil, err
 		}
-
-		body = &buf
+		body, contentType = buf, defaultType
 	}
 
 	req, err := http.NewRequest(cfg.Method, url, body)
@@ -310,7 +812,13 @@ func (r *TestRunner) buildRequest(cfg RequestConfig) (*http.Request, error) {
 		return nil, err
 	}
 
-	// 设置请求头
+	// 设置请求头，content_type 和 headers 覆盖默认的 Content-Type
+	if cfg.ContentType != "" && cfg.bodyType() != BodyTypeMultipart {
+		contentType = r.replaceVariables(cfg.ContentType)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 	for k, v := range cfg.Headers {
 		req.Header.Set(k, r.replaceVariables(v))
 	}
@@ -490,128 +998,169 @@ func parseNumber(s string) (any, bool) {
 	return nil, false
 }
 
-// validateExpectation 验证期望结果
-func (r *TestRunner) validateExpectation(expect ExpectConfig, statusCode int, respData map[string]any) error {
-	fmt.Printf("DEBUG: validateExpectation - Expect.StatusCode: %d, Actual Status Code: %d\n", expect.StatusCode, statusCode) // ADDED DEBUG
-	// 验证状态码
-	if expect.StatusCode != 0 && expect.StatusCode != statusCode {
-		return fmt.Errorf("status code mismatch: expected %d, got %d", expect.StatusCode, statusCode)
+// validateExpectation 验证期望结果，返回已执行的校验项结果
+// 默认遇到第一个失败时停止；soft 为 true 时执行全部校验项，返回汇总所有失败的错误
+func (r *TestRunner) validateExpectation(expect ExpectConfig, soft bool, statusCode int, resp *response) ([]AssertionResult, error) {
+	var outcomes []AssertionResult
+	var errs []error
+	// fail 记录失败的校验项，返回是否应当停止
+	fail := func(outcome AssertionResult, err error) bool {
+		outcome.Error = err.Error()
+		outcomes = append(outcomes, outcome)
+		errs = append(errs, err)
+		return !soft
 	}
 
-	// 验证 response_body 中的字段（code、data 等）
-	if expect.ResponseBody != nil {
-		for key, expectedValue := range expect.ResponseBody {
-			actualValue, ok := respData[key]
-			if !ok {
-				return fmt.Errorf("field '%s' not found in response", key)
+	// 验证状态码
+	if expect.StatusCode != 0 {
+		outcome := AssertionResult{Path: "status_code", Operator: "equals", Expected: expect.StatusCode, Actual: statusCode}
+		if expect.StatusCode != statusCode {
+			if fail(outcome, fmt.Errorf("status code mismatch: expected %d, got %d", expect.StatusCode, statusCode)) {
+				return outcomes, errs[0]
 			}
+		} else {
+			outcome.Passed = true
+			outcomes = append(outcomes, outcome)
+		}
+	}
 
-			// 特殊处理 null 值
-			if expectedValue == nil {
-				if actualValue != nil {
-					return fmt.Errorf("field '%s' expected null, got %v", key, actualValue)
-				}
-				continue
+	// 使用 JSON Schema 校验整个响应体，一次报告所有违反项
+	if expect.Schema != nil {
+		schemaOutcomes, err := r.validateSchema(expect.Schema, resp.data)
+		outcomes = append(outcomes, schemaOutcomes...)
+		if err != nil {
+			errs = append(errs, err)
+			if !soft {
+				return outcomes, err
 			}
+		}
+	}
 
-			// 比较值
-			if fmt.Sprint(actualValue) != fmt.Sprint(expectedValue) {
-				return fmt.Errorf("field '%s' mismatch: expected %v, got %v", key, expectedValue, actualValue)
+	// 验证 response_body 中的字段（code、data 等），按字段名排序保证结果稳定
+	keys := make([]string, 0, len(expect.ResponseBody))
+	for key := range expect.ResponseBody {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	respObject, _ := resp.data.(map[string]any)
+	for _, key := range keys {
+		expectedValue := expect.ResponseBody[key]
+		actualValue, ok := respObject[key]
+		outcome := AssertionResult{Path: key, Operator: "equals", Expected: expectedValue, Actual: actualValue}
+		if err := checkResponseField(key, expectedValue, actualValue, ok); err != nil {
+			if fail(outcome, err) {
+				return outcomes, err
 			}
+			continue
 		}
+		outcome.Passed = true
+		outcomes = append(outcomes, outcome)
 	}
 
 	// 执行断言
 	for _, assertion := range expect.Assertions {
-		if err := r.executeAssertion(assertion, respData); err != nil {
-			return err
+		outcome := AssertionResult{
+			Path:     assertion.Path,
+			Operator: assertion.Operator,
+			Expected: assertion.Value,
+			Actual:   r.getValueByPath(assertion.Path, resp),
 		}
+		if err := r.executeAssertion(assertion, resp); err != nil {
+			if fail(outcome, err) {
+				return outcomes, err
+			}
+			continue
+		}
+		outcome.Passed = true
+		outcomes = append(outcomes, outcome)
 	}
 
+	switch len(errs) {
+	case 0:
+		return outcomes, nil
+	case 1:
+		return outcomes, errs[0]
+	}
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return outcomes, fmt.Errorf("%d checks failed: %s", len(errs), strings.Join(msgs, "; "))
+}
+
+// validateSchema 使用 expect.schema 校验响应体，每个违反项记录为一条校验结果
+func (r *TestRunner) validateSchema(ref any, respData any) ([]AssertionResult, error) {
+	schema, err := r.loadSchema(ref)
+	if err != nil {
+		return []AssertionResult{{Path: "schema", Operator: "schema", Error: err.Error()}}, err
+	}
+
+	violations := ValidateSchema(schema, respData)
+	if len(violations) == 0 {
+		return []AssertionResult{{Path: "schema", Operator: "schema", Passed: true}}, nil
+	}
+
+	outcomes := make([]AssertionResult, 0, len(violations))
+	messages := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		outcomes = append(outcomes, AssertionResult{
+			Path:     violation.Pointer,
+			Operator: "schema",
+			Error:    violation.Message,
+		})
+		messages = append(messages, violation.String())
+	}
+	return outcomes, fmt.Errorf("schema validation failed with %d violation(s): %s", len(violations), strings.Join(messages, "; "))
+}
+
+// checkResponseField 校验 response_body 中的单个字段
+func checkResponseField(key string, expectedValue, actualValue any, found bool) error {
+	if !found {
+		return fmt.Errorf("field '%s' not found in response", key)
+	}
+
+	// 特殊处理 null 值
+	if expectedValue == nil {
+		if actualValue != nil {
+			return fmt.Errorf("field '%s' expected null, got %v", key, actualValue)
+		}
+		return nil
+	}
+
+	// 比较值
+	if fmt.Sprint(actualValue) != fmt.Sprint(expectedValue) {
+		return fmt.Errorf("field '%s' mismatch: expected %v, got %v", key, expectedValue, actualValue)
+	}
 	return nil
 }
 
-// executeAssertion 执行断言
-func (r *TestRunner) executeAssertion(assertion Assertion, data map[string]any) error {
-	value := r.getValueByPath(assertion.Path, data)
+// executeAssertion 执行断言，操作符从注册表中查找，见 RegisterOperator
+// data 为 *response 时还支持 body_text、body_size、body_sha256 和 xpath: 路径
+func (r *TestRunner) executeAssertion(assertion Assertion, data any) error {
+	value, found, err := lookupValue(assertion.Path, data)
+	if err != nil {
+		return fmt.Errorf("assertion failed: %w", err)
+	}
 	expectedValue := assertion.Value
 
-	// 如果期望值是字符串,替换变量
-	if strVal, ok := expectedValue.(string); ok {
-		expectedValue = r.replaceVariables(strVal)
+	// 替换期望值中的变量，对象和数组（in、between、each 等）递归替换
+	switch val := expectedValue.(type) {
+	case string:
+		expectedValue = r.replaceVariables(val)
+	case map[string]any:
+		expectedValue = r.replaceMapVariables(val)
+	case []any:
+		expectedValue = r.replaceMapVariables(map[string]any{"": val})[""]
 	}
 
-	switch assertion.Operator {
-	case "equals":
-		// 🔧 修改点3: 使用类型安全的比较
-		if !valuesEqual(value, expectedValue) {
-			return fmt.Errorf("assertion failed: %s should equal %v (type: %T), got %v (type: %T)",
-				assertion.Path, expectedValue, expectedValue, value, value)
-		}
-	case "notEquals":
-		if valuesEqual(value, expectedValue) {
-			return fmt.Errorf("assertion failed: %s should not equal %v", assertion.Path, expectedValue)
-		}
-	case "contains":
-		str := fmt.Sprint(value)
-		substr := fmt.Sprint(expectedValue)
-		if !strings.Contains(str, substr) {
-			return fmt.Errorf("assertion failed: %s should contain %s, got %s", assertion.Path, substr, str)
-		}
-	case "startsWith":
-		str := fmt.Sprint(value)
-		prefix := fmt.Sprint(expectedValue)
-		if !strings.HasPrefix(str, prefix) {
-			return fmt.Errorf("assertion failed: %s should start with %s, got %s", assertion.Path, prefix, str)
-		}
-	case "notEmpty":
-		if value == nil || value == "" {
-			return fmt.Errorf("assertion failed: %s should not be empty", assertion.Path)
-		}
-	case "isArray":
-		if _, ok := value.([]any); !ok {
-			return fmt.Errorf("assertion failed: %s should be array", assertion.Path)
-		}
-	case "greaterThan":
-		numVal, ok := toFloat64(value)
-		if !ok {
-			return fmt.Errorf("assertion failed: %s should be number", assertion.Path)
-		}
-		expectedNum, ok := toFloat64(expectedValue)
-		if !ok {
-			return fmt.Errorf("assertion failed: expected value should be number")
-		}
-		if numVal <= expectedNum {
-			return fmt.Errorf("assertion failed: %s should be greater than %v, got %v", assertion.Path, expectedNum, numVal)
-		}
-	case "greaterThanOrEqual":
-		numVal, ok := toFloat64(value)
-		if !ok {
-			return fmt.Errorf("assertion failed: %s should be number", assertion.Path)
-		}
-		expectedNum, ok := toFloat64(expectedValue)
-		if !ok {
-			return fmt.Errorf("assertion failed: expected value should be number")
-		}
-		if numVal < expectedNum {
-			return fmt.Errorf("assertion failed: %s should be >= %v, got %v", assertion.Path, expectedNum, numVal)
-		}
-	case "lessThan":
-		numVal, ok := toFloat64(value)
-		if !ok {
-			return fmt.Errorf("assertion failed: %s should be number", assertion.Path)
-		}
-		expectedNum, ok := toFloat64(expectedValue)
-		if !ok {
-			return fmt.Errorf("assertion failed: expected value should be number")
-		}
-		if numVal >= expectedNum {
-			return fmt.Errorf("assertion failed: %s should be less than %v, got %v", assertion.Path, expectedNum, numVal)
-		}
-	default:
+	fn, ok := lookupOperator(assertion.Operator)
+	if !ok {
 		return fmt.Errorf("unknown operator: %s", assertion.Operator)
 	}
-
+	if err := fn(AssertionContext{Path: assertion.Path, Actual: value, Found: found, Expected: expectedValue}); err != nil {
+		return fmt.Errorf("assertion failed: %w", err)
+	}
 	return nil
 }
 
@@ -696,58 +1245,87 @@ func toFloat64(v any) (float64, bool) {
 	}
 }
 
-// getValueByPath 通过路径获取值
-func (r *TestRunner) getValueByPath(path string, data map[string]any) any {
-	parts := strings.Split(path, ".")
-	var current any = data
-
-	for _, part := range parts {
-		// 处理数组索引 data[0]
-		if strings.Contains(part, "[") {
-			arrayPart := part[:strings.Index(part, "[")]
-			indexPart := part[strings.Index(part, "[")+1 : strings.Index(part, "]")]
+// valueLength 返回数组、对象或字符串（按字符计算）的长度
+func valueLength(v any) (int, bool) {
+	switch val := v.(type) {
+	case []any:
+		return len(val), true
+	case map[string]any:
+		return len(val), true
+	case string:
+		return len([]rune(val)), true
+	}
+	return 0, false
+}
 
-			if m, ok := current.(map[string]any); ok {
-				current = m[arrayPart]
-			}
+// getValueByPath 通过 JSONPath 获取值，路径无效或不存在时返回 nil
+// 通配符、过滤器等不确定路径返回 []any，语法见 jsonPath；data 为 *response 时同 executeAssertion
+func (r *TestRunner) getValueByPath(path string, data any) any {
+	value, _, _ := lookupValue(path, data)
+	return value
+}
 
-			if arr, ok := current.([]any); ok {
-				var index int
-				fmt.Sscanf(indexPart, "%d", &index)
-				if index < len(arr) {
-					current = arr[index]
-				} else {
-					return nil
-				}
-			}
-		} else {
-			if m, ok := current.(map[string]any); ok {
-				current = m[part]
-			} else {
-				return nil
-			}
-		}
+// decodeJSON 使用 safejson 解析任意 JSON 值（对象、数组或标量），避免大整数精度丢失
+func decodeJSON(data []byte) (any, error) {
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil, nil
 	}
-
-	return current
+	var v any
+	if err := safejson.SafeUnmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
 }
 
-// saveVariables 保存变量
-func (r *TestRunner) saveVariables(save map[string]string, respData map[string]any) {
-	for varName, path := range save {
-		value := r.getValueByPath(path, respData)
+// saveVariables 保存变量，返回本次保存的变量
+// 来源可以是 JSONPath、header.、cookie.、xpath: 等断言路径，见 savedValue
+func (r *TestRunner) saveVariables(save map[string]string, resp *response) (map[string]any, error) {
+	saved := make(map[string]any, len(save))
+	for varName, source := range save {
+		value, err := r.savedValue(source, resp)
+		if err != nil {
+			return saved, err
+		}
 
 		// 🔧 修改点4: safejson 已经自动将大整数转换为 int64/uint64
 		// 不需要手动转换，只保留调试日志
 
 		r.variables[varName] = value
-		fmt.Printf("    💾 Saved variable: %s = %v (type: %T)\n", varName, value, value)
+		saved[varName] = value
+		fmt.Fprintf(r.out, "    💾 Saved variable: %s = %v (type: %T)\n", varName, value, value)
 	}
+	return saved, nil
+}
+
+// savedValue 按来源取值；"路径 | 正则" 形式保存正则的第一个捕获组（没有捕获组时为整个匹配），
+// 例如 "header.Location | /users/(\d+)$"。路径不存在或正则不匹配时保存 nil
+func (r *TestRunner) savedValue(source string, resp *response) (any, error) {
+	path, pattern, hasPattern := strings.Cut(source, " | ")
+	value := r.getValueByPath(strings.TrimSpace(path), resp)
+	if !hasPattern {
+		return value, nil
+	}
+
+	re, err := regexp.Compile(strings.TrimSpace(pattern))
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression in save '%s': %w", source, err)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	match := re.FindStringSubmatch(fmt.Sprint(value))
+	switch {
+	case match == nil:
+		return nil, nil
+	case len(match) > 1:
+		return match[1], nil
+	}
+	return match[0], nil
 }
 
 // isDependencyPassed 检查依赖是否通过
 func (r *TestRunner) isDependencyPassed(name string) bool {
-	for _, result := range r.results {
+	for _, result := range r.GetResults() {
 		if result.Name == name {
 			return result.Passed
 		}
@@ -757,25 +1335,25 @@ func (r *TestRunner) isDependencyPassed(name string) bool {
 
 // executeSetup 执行 setup
 func (r *TestRunner) executeSetup(ctx context.Context) error {
-	fmt.Println("🔧 Executing setup...")
+	fmt.Fprintln(r.out, "🔧 Executing setup...")
 	for _, action := range r.suite.Suite.Setup {
 		if err := r.executeAction(ctx, action); err != nil {
 			return err
 		}
 	}
-	fmt.Println("✓ Setup completed")
+	fmt.Fprintln(r.out, "✓ Setup completed")
 	return nil
 }
 
 // executeTeardown 执行 teardown
 func (r *TestRunner) executeTeardown(ctx context.Context) error {
-	fmt.Println("\n🔧 Executing teardown...")
+	fmt.Fprintln(r.out, "\n🔧 Executing teardown...")
 	for _, action := range r.suite.Suite.Teardown {
 		if err := r.executeAction(ctx, action); err != nil {
 			return err
 		}
 	}
-	fmt.Println("✓ Teardown completed")
+	fmt.Fprintln(r.out, "✓ Teardown completed")
 	return nil
 }
 
@@ -783,6 +1361,16 @@ func (r *TestRunner) SetBaseURL(url string) {
 	r.suite.Suite.BaseURL = url
 }
 
+// ConfigPath 获取配置文件路径
+func (r *TestRunner) ConfigPath() string {
+	return r.configPath
+}
+
+// BaseURL 获取 API 基础 URL
+func (r *TestRunner) BaseURL() string {
+	return r.suite.Suite.BaseURL
+}
+
 // executeAction 执行清理动作
 func (r *TestRunner) executeAction(ctx context.Context, action SetupAction) error {
 	switch action.Type {
@@ -831,9 +1419,9 @@ func (r *TestRunner) softDeleteCleanup(ctx context.Context, table, condition str
 	// 获取影响行数
 	if sqlResult, ok := result.(interface{ RowsAffected() (int64, error) }); ok {
 		rows, _ := sqlResult.RowsAffected()
-		fmt.Printf("  ✓ Soft deleted %d rows from table '%s'\n", rows, table)
+		fmt.Fprintf(r.out, "  ✓ Soft deleted %d rows from table '%s'\n", rows, table)
 	} else {
-		fmt.Printf("  ✓ Soft delete cleanup executed on table '%s'\n", table)
+		fmt.Fprintf(r.out, "  ✓ Soft delete cleanup executed on table '%s'\n", table)
 	}
 
 	return nil
@@ -841,12 +1429,15 @@ func (r *TestRunner) softDeleteCleanup(ctx context.Context, table, condition str
 
 // executeAPICall 执行 API 调用（用于 setup/teardown）
 func (r *TestRunner) executeAPICall(ctx context.Context, reqCfg RequestConfig) error {
-	req, err := r.buildRequest(reqCfg)
+	req, err := r.newRequest(ctx, reqCfg, r.authFor(TestCase{}))
 	if err != nil {
-		return fmt.Errorf("build request failed: %w", err)
+		return err
+	}
+	if err := r.beforeRequest(ctx, req); err != nil {
+		return err
 	}
 
-	resp, err := r.client.Do(req)
+	resp, err := r.clientFor("").Do(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -865,8 +1456,9 @@ func (r *TestRunner) printSummary() {
 	passed := 0
 	failed := 0
 	totalDuration := time.Duration(0)
+	results := r.GetResults()
 
-	for _, result := range r.results {
+	for _, result := range results {
 		totalDuration += result.Duration
 		if result.Passed {
 			passed++
@@ -875,36 +1467,108 @@ func (r *TestRunner) printSummary() {
 		}
 	}
 
-	fmt.Printf("═══════════════════════════════════════════════════════\n")
-	fmt.Printf("📊 Test Summary\n")
-	fmt.Printf("═══════════════════════════════════════════════════════\n")
-	fmt.Printf("Total Tests:     %d\n", len(r.results))
-	fmt.Printf("✓ Passed:        %d\n", passed)
-	fmt.Printf("✗ Failed:        %d\n", failed)
-	fmt.Printf("⏱  Duration:      %.2fs\n", totalDuration.Seconds())
-	fmt.Printf("═══════════════════════════════════════════════════════\n")
+	fmt.Fprintf(r.out, "═══════════════════════════════════════════════════════\n")
+	fmt.Fprintf(r.out, "📊 Test Summary\n")
+	fmt.Fprintf(r.out, "═══════════════════════════════════════════════════════\n")
+	fmt.Fprintf(r.out, "Total Tests:     %d\n", len(results))
+	fmt.Fprintf(r.out, "✓ Passed:        %d\n", passed)
+	fmt.Fprintf(r.out, "✗ Failed:        %d\n", failed)
+	fmt.Fprintf(r.out, "⏱  Duration:      %.2fs\n", totalDuration.Seconds())
+	fmt.Fprintf(r.out, "═══════════════════════════════════════════════════════\n")
 
 	if failed > 0 {
-		fmt.Printf("\n❌ Failed Tests:\n")
-		for _, result := range r.results {
+		fmt.Fprintf(r.out, "\n❌ Failed Tests:\n")
+		for _, result := range results {
 			if !result.Passed {
-				fmt.Printf("  [%s] %s\n", result.Scenario, result.Name)
-				fmt.Printf("    Error: %s\n", result.Error)
+				fmt.Fprintf(r.out, "  [%s] %s\n", result.Scenario, result.Name)
+				fmt.Fprintf(r.out, "    Error: %s\n", result.Error)
 			}
 		}
 	} else {
-		fmt.Printf("\n🎉 All tests passed!\n")
+		fmt.Fprintf(r.out, "\n🎉 All tests passed!\n")
+	}
+}
+
+// DefaultRedactHeaders 默认在导出结果中脱敏的头
+var DefaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// redactedValue 脱敏后的头取值
+const redactedValue = "[REDACTED]"
+
+// exportedResults 返回用于导出的结果副本，敏感头和 URL 中的 API Key 查询参数已脱敏
+func (r *TestRunner) exportedResults() []TestResult {
+	results := r.GetResults()
+	if len(r.redact) == 0 && len(r.redactQuery) == 0 {
+		return results
+	}
+
+	for i := range results {
+		if req := results[i].Request; req != nil {
+			redacted := *req
+			redacted.URL = redactQuery(req.URL, r.redactQuery)
+			redacted.Headers = redactHeaders(req.Headers, r.redact)
+			results[i].Request = &redacted
+		}
+		if resp := results[i].Response; resp != nil {
+			redacted := *resp
+			redacted.Headers = redactHeaders(resp.Headers, r.redact)
+			results[i].Response = &redacted
+		}
+	}
+	return results
+}
+
+// redactHeaders 返回 headers 的副本，names 中的头（不区分大小写）取值替换为 [REDACTED]
+func redactHeaders(headers map[string][]string, names []string) map[string][]string {
+	if headers == nil {
+		return nil
+	}
+
+	redacted := make(map[string][]string, len(headers))
+	for k, v := range headers {
+		redacted[k] = v
+		for _, name := range names {
+			if strings.EqualFold(k, name) {
+				redacted[k] = []string{redactedValue}
+				break
+			}
+		}
+	}
+	return redacted
+}
+
+// redactQuery 返回 rawURL 中 names 指定的查询参数取值替换为 [REDACTED] 后的 URL，保持参数顺序
+func redactQuery(rawURL string, names []string) string {
+	base, query, ok := strings.Cut(rawURL, "?")
+	if !ok || len(names) == 0 {
+		return rawURL
+	}
+	query, fragment, hasFragment := strings.Cut(query, "#")
+
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); err == nil && slices.Contains(names, name) {
+			params[i] = key + "=" + redactedValue
+		}
+	}
+	redacted := base + "?" + strings.Join(params, "&")
+	if hasFragment {
+		redacted += "#" + fragment
 	}
+	return redacted
 }
 
-// GetResults 获取测试结果
+// GetResults 获取测试结果（按场景声明顺序）
 func (r *TestRunner) GetResults() []TestResult {
-	return r.results
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]TestResult(nil), r.results...)
 }
 
 // ExportResults 导出测试结果为 JSON
 func (r *TestRunner) ExportResults(filepath string) error {
-	data, err := json.MarshalIndent(r.results, "", "  ")
+	data, err := json.MarshalIndent(r.exportedResults(), "", "  ")
 	if err != nil {
 		return err
 	}
@@ -913,5 +1577,7 @@ func (r *TestRunner) ExportResults(filepath string) error {
 
 // SetResults 设置测试结果（用于批量导出）
 func (r *TestRunner) SetResults(results []TestResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.results = results
 }
@@ -2,50 +2,50 @@ package apitest
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
+// mockServerSuite describes the mock HTTP server shared by the tests
+const mockServerSuite = `
+suite:
+  name: "Mock Users"
+  base_url: ""
+mocks:
+  - name: "Get user 1"
+    request: { method: "GET", path: "/users/1" }
+    response:
+      body: { id: 1, name: "John Doe", email: "john.doe@example.com" }
+  - name: "Create user"
+    request: { method: "POST", path: "/users", body: { username: "*" } }
+    response:
+      status: 201
+      body: { id: 2, message: "User created" }
+  - name: "Invalid user"
+    request: { method: "POST", path: "/users" }
+    response:
+      status: 400
+      body: { error: "Invalid request body" }
+`
+
 // Helper function to setup a mock HTTP server
 func setupMockServer() *httptest.Server {
-	mux := http.NewServeMux()
-
-	// Handler for specific user ID (e.g., GET /users/1)
-	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/1") {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			io.WriteString(w, `{"id": 1, "name": "John Doe", "email": "john.doe@example.com"}`)
-			return
-		}
-		w.WriteHeader(http.StatusNotFound) // Default for other /users/ paths or methods
-	})
-
-	// Handler for /users (e.g., POST /users)
-	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case "POST":
-			body, _ := io.ReadAll(r.Body)
-			if strings.Contains(string(body), "username") { // Basic check for body content
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusCreated)
-				io.WriteString(w, `{"id": 2, "message": "User created"}`)
-			} else {
-				w.WriteHeader(http.StatusBadRequest)
-				io.WriteString(w, `{"error": "Invalid request body"}`)
-			}
-		default:
-			w.WriteHeader(http.StatusMethodNotAllowed)
-		}
-	})
-
-	return httptest.NewServer(mux)
+	suite, err := ParseSuite([]byte(mockServerSuite))
+	if err != nil {
+		panic(err)
+	}
+	mock := NewMockServer(suite)
+	mock.SetOutput(io.Discard)
+	return httptest.NewServer(mock)
 }
 
 // TestMain sets up and tears down common resources for all tests in this package.
@@ -280,3 +280,421 @@ func TestTestRunnerExportResults(t *testing.T) {
 		t.Errorf("Exported file is empty")
 	}
 }
+
+func TestTestRunnerParallelScenarios(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sleep", func(w http.ResponseWriter, r *http.Request) {
+		d, _ := time.ParseDuration(r.URL.Query().Get("d"))
+		time.Sleep(d)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"value": "`+r.URL.Query().Get("v")+`"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "parallel.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "Parallel Suite"
+  base_url: "`+server.URL+`"
+variables:
+  shared: "initial"
+scenarios:
+  - name: "Slow"
+    parallel: true
+    testcases:
+      - name: "Slow Request"
+        request: { method: "GET", path: "/sleep", query: { d: "150ms", v: "slow" } }
+        expect: { status_code: 200 }
+        save: { shared: "value" }
+      - name: "Slow Uses Own Scope"
+        request: { method: "GET", path: "/sleep", query: { v: "{{shared}}" } }
+        expect:
+          status_code: 200
+          assertions:
+            - { path: "value", operator: "equals", value: "slow" }
+  - name: "Fast"
+    parallel: true
+    testcases:
+      - name: "Fast Request"
+        request: { method: "GET", path: "/sleep", query: { v: "{{shared}}" } }
+        expect:
+          status_code: 200
+          assertions:
+            - { path: "value", operator: "equals", value: "initial" }
+  - name: "Sequential"
+    testcases:
+      - name: "Depends On Parallel Case"
+        depends_on: "Fast Request"
+        request: { method: "GET", path: "/sleep", query: { v: "{{shared}}" } }
+        expect:
+          status_code: 200
+          assertions:
+            - { path: "value", operator: "equals", value: "initial" }
+`), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("Failed to create TestRunner: %v", err)
+	}
+	var out strings.Builder
+	runner.SetOutput(&out)
+	runner.SetParallelism(4)
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("TestRunner.Run failed: %v", err)
+	}
+
+	results := runner.GetResults()
+	expected := []string{"Slow Request", "Slow Uses Own Scope", "Fast Request", "Depends On Parallel Case"}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(results))
+	}
+	for i, name := range expected {
+		if results[i].Name != name {
+			t.Errorf("Result %d: expected '%s', got '%s'", i, name, results[i].Name)
+		}
+		if !results[i].Passed {
+			t.Errorf("Test '%s' failed unexpectedly: %s", results[i].Name, results[i].Error)
+		}
+	}
+
+	if strings.Index(out.String(), "Scenario: Slow") > strings.Index(out.String(), "Scenario: Fast") {
+		t.Errorf("Expected scenario output in declaration order, got:\n%s", out.String())
+	}
+}
+
+func TestTestRunnerShareParallelism(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		inFlight.Add(-1)
+	}))
+	defer server.Close()
+
+	var scenarios strings.Builder
+	for i := 0; i < 4; i++ {
+		fmt.Fprintf(&scenarios, `
+  - name: "Scenario %d"
+    parallel: true
+    testcases:
+      - name: "Request %d"
+        request: { method: "GET", path: "/" }
+        expect: { status_code: 200 }`, i, i)
+	}
+	configPath := filepath.Join(t.TempDir(), "shared.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "Shared Parallelism"
+  base_url: "`+server.URL+`"
+scenarios:`+scenarios.String()+"\n"), 0644)
+
+	// 两个运行器共享 2 个令牌，各自的并发数为 2，同时运行的请求不应超过 2 个
+	slots := make(chan struct{}, 2)
+	var wg sync.WaitGroup
+	runners := make([]*TestRunner, 2)
+	for i := range runners {
+		runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+		if err != nil {
+			t.Fatalf("Failed to create TestRunner: %v", err)
+		}
+		runner.SetOutput(io.Discard)
+		runner.SetParallelism(2)
+		runner.ShareParallelism(slots)
+		runners[i] = runner
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			runner.Run(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	for _, runner := range runners {
+		for _, result := range runner.GetResults() {
+			if !result.Passed {
+				t.Errorf("Test '%s' failed unexpectedly: %s", result.Name, result.Error)
+			}
+		}
+	}
+	if got := peak.Load(); got > 2 {
+		t.Errorf("Expected at most 2 concurrent requests, got %d", got)
+	}
+}
+
+func TestTestRunnerExportHTML(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "html.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "HTML Suite"
+  base_url: "`+server.URL+`"
+variables:
+  username: "html_user"
+scenarios:
+  - name: "Users"
+    testcases:
+      - name: "Create User"
+        request: { method: "POST", path: "/users", headers: { X-Trace: "trace-1" }, body: { username: "{{username}}" } }
+        expect:
+          status_code: 201
+          assertions:
+            - { path: "message", operator: "equals", value: "User created" }
+        save: { created_id: "id" }
+      - name: "Get Missing User"
+        request: { method: "GET", path: "/users/404" }
+        expect: { status_code: 200 }
+`), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("Failed to create TestRunner: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("TestRunner.Run failed: %v", err)
+	}
+
+	results := runner.GetResults()
+	created := results[0]
+	if created.Request == nil || created.Request.Method != "POST" || !strings.HasSuffix(created.Request.URL, "/users") {
+		t.Fatalf("Expected captured POST request, got %+v", created.Request)
+	}
+	if !strings.Contains(created.Request.Body, `"username":"html_user"`) {
+		t.Errorf("Expected request body after variable substitution, got %s", created.Request.Body)
+	}
+	if len(created.Assertions) != 2 || !created.Assertions[1].Passed {
+		t.Errorf("Expected status and body assertion outcomes, got %+v", created.Assertions)
+	}
+	if created.Saved["created_id"] == nil {
+		t.Errorf("Expected saved variable in result, got %+v", created.Saved)
+	}
+	if missing := results[1]; missing.Passed || len(missing.Assertions) != 1 || missing.Assertions[0].Passed {
+		t.Errorf("Expected failed status code outcome, got %+v", missing.Assertions)
+	}
+
+	reportPath := filepath.Join(tempDir, "report.html")
+	if err := runner.ExportHTML(reportPath); err != nil {
+		t.Fatalf("ExportHTML failed: %v", err)
+	}
+	report, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("HTML report not found: %v", err)
+	}
+	for _, want := range []string{"HTML Suite", "Create User", "html_user", "X-Trace", "status code mismatch"} {
+		if !strings.Contains(string(report), want) {
+			t.Errorf("Expected HTML report to contain %q", want)
+		}
+	}
+}
+
+func TestTestRunnerExportRedactsHeaders(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			// 前两次直接断开连接，触发重试
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"ok": true}`)
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "redact.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "Redact Suite"
+  base_url: "`+server.URL+`"
+  redact_headers: [X-Signature]
+scenarios:
+  - name: "Auth"
+    testcases:
+      - name: "Retried Request"
+        request:
+          method: "GET"
+          path: "/items"
+          query: { page: "2" }
+          headers: { Authorization: "Bearer secret-token", X-Signature: "sig", X-Trace: "trace-1" }
+        expect: { status_code: 200 }
+        retry: { times: 3, interval: 10 }
+`), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("Failed to create TestRunner: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("TestRunner.Run failed: %v", err)
+	}
+
+	result := runner.GetResults()[0]
+	if !result.Passed {
+		t.Fatalf("Test '%s' failed unexpectedly: %s", result.Name, result.Error)
+	}
+	if result.Request.Attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", result.Request.Attempts)
+	}
+	if !strings.HasSuffix(result.Request.URL, "/items?page=2") {
+		t.Errorf("Expected final URL with query, got %s", result.Request.URL)
+	}
+	if result.Request.Headers["Authorization"][0] != "Bearer secret-token" {
+		t.Errorf("GetResults should keep original headers, got %v", result.Request.Headers["Authorization"])
+	}
+
+	exportPath := filepath.Join(tempDir, "results.json")
+	if err := runner.ExportResults(exportPath); err != nil {
+		t.Fatalf("ExportResults failed: %v", err)
+	}
+	exported, _ := os.ReadFile(exportPath)
+	for _, secret := range []string{"secret-token", "session=secret", `"sig"`} {
+		if strings.Contains(string(exported), secret) {
+			t.Errorf("Exported results should not contain %q", secret)
+		}
+	}
+	if !strings.Contains(string(exported), "trace-1") {
+		t.Error("Exported results should keep non-sensitive headers")
+	}
+
+	runner.SetRedactHeaders()
+	if err := runner.ExportResults(exportPath); err != nil {
+		t.Fatalf("ExportResults failed: %v", err)
+	}
+	exported, _ = os.ReadFile(exportPath)
+	if !strings.Contains(string(exported), "secret-token") {
+		t.Error("Expected redaction to be disabled after SetRedactHeaders()")
+	}
+}
+
+func TestTestRunnerExportRedactsQueryAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"ok": true}`)
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "redact_query.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "Redact Query Suite"
+  base_url: "`+server.URL+`"
+  auth: { type: api_key, in: query, name: api_key, value: "query-secret" }
+scenarios:
+  - name: "Auth"
+    testcases:
+      - name: "Keyed Request"
+        request:
+          method: "GET"
+          path: "/items"
+          query: { page: "2" }
+        expect: { status_code: 200 }
+`), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("Failed to create TestRunner: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("TestRunner.Run failed: %v", err)
+	}
+	if url := runner.GetResults()[0].Request.URL; !strings.Contains(url, "api_key=query-secret") {
+		t.Errorf("GetResults should keep the original URL, got %s", url)
+	}
+
+	exportPath := filepath.Join(tempDir, "results.json")
+	if err := runner.ExportResults(exportPath); err != nil {
+		t.Fatalf("ExportResults failed: %v", err)
+	}
+	exported, _ := os.ReadFile(exportPath)
+	if strings.Contains(string(exported), "query-secret") {
+		t.Error("Exported results should not contain the query API key")
+	}
+	if !strings.Contains(string(exported), "api_key=[REDACTED]") || !strings.Contains(string(exported), "page=2") {
+		t.Errorf("Expected masked API key and kept query parameters, got:\n%s", exported)
+	}
+}
+
+func TestTestRunnerSoftAssertions(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "soft.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "Soft Suite"
+  base_url: "`+server.URL+`"
+  soft_assertions: true
+scenarios:
+  - name: "Users"
+    testcases:
+      - name: "Many Wrong Fields"
+        request: { method: "GET", path: "/users/1" }
+        expect:
+          status_code: 201
+          response_body: { id: 2, name: "John Doe" }
+          assertions:
+            - { path: "email", operator: "endsWith", value: "@example.org" }
+            - { path: "name", operator: "startsWith", value: "John" }
+      - name: "Hard Case"
+        request: { method: "GET", path: "/users/1" }
+        expect:
+          soft: false
+          status_code: 201
+          response_body: { id: 2 }
+`), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("Failed to create TestRunner: %v", err)
+	}
+	var out strings.Builder
+	runner.SetOutput(&out)
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("TestRunner.Run failed: %v", err)
+	}
+
+	soft := runner.GetResults()[0]
+	if soft.Passed || len(soft.Assertions) != 5 || len(soft.Failures) != 3 {
+		t.Fatalf("Expected every check evaluated with 3 failures, got %+v", soft)
+	}
+	var paths []string
+	for _, f := range soft.Failures {
+		paths = append(paths, f.Path)
+	}
+	if strings.Join(paths, ",") != "status_code,id,email" {
+		t.Errorf("Expected failures in evaluation order, got %v", paths)
+	}
+	if !strings.HasPrefix(soft.Error, "3 checks failed: status code mismatch") {
+		t.Errorf("Expected combined error, got %q", soft.Error)
+	}
+	if !strings.Contains(out.String(), "✗ Many Wrong Fields") || !strings.Contains(out.String(), "     • assertion failed: email should end with @example.org") {
+		t.Errorf("Expected every failure printed, got:\n%s", out.String())
+	}
+
+	hard := runner.GetResults()[1]
+	if len(hard.Assertions) != 1 || len(hard.Failures) != 1 {
+		t.Errorf("Expected expect.soft: false to stop at the first failure, got %+v", hard.Assertions)
+	}
+}
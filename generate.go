@@ -0,0 +1,527 @@
+package apitest
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GenerateOptions 从 OpenAPI 规范生成测试套件的选项
+type GenerateOptions struct {
+	Name         string // 套件名，默认使用规范的 info.title
+	BaseURL      string // 默认使用 servers 中的第一个地址
+	SkipNegative bool   // 不生成缺少必填字段、类型错误的反向用例
+}
+
+// GenerateSuite 从 OpenAPI 规范生成测试套件
+//
+// 每个 tag 对应一个场景（未打 tag 的操作归入 default），每个操作生成一个正向用例：
+// 参数和请求体取自 schema 中的 example/default/enum，期望状态码取声明的最小 2xx 响应码。
+// 创建操作（POST）返回 id 时生成 save，后续路径参数引用该变量并依赖创建用例。
+// 带 JSON 请求体的操作额外生成缺少必填字段和字段类型错误的反向用例，期望声明的 4xx 响应码。
+func GenerateSuite(spec *OpenAPISpec, opts GenerateOptions) *TestSuite {
+	g := &suiteGenerator{spec: spec, opts: opts, ids: make(map[string]savedID)}
+
+	suite := &TestSuite{
+		Suite: SuiteConfig{Name: opts.Name, BaseURL: opts.BaseURL},
+	}
+	if suite.Suite.Name == "" {
+		suite.Suite.Name = spec.Title
+	}
+	if suite.Suite.BaseURL == "" && len(spec.Servers) > 0 {
+		suite.Suite.BaseURL = strings.TrimSuffix(spec.Servers[0], "/")
+	}
+
+	byTag := make(map[string][]*OpenAPIOperation)
+	for _, op := range g.orderedOperations() {
+		tag := "default"
+		if len(op.Tags) > 0 {
+			tag = op.Tags[0]
+		}
+		byTag[tag] = append(byTag[tag], op)
+	}
+
+	for _, tag := range g.tagOrder(byTag) {
+		scenario := Scenario{Name: tag, Description: g.tagDescription(tag)}
+		for _, op := range byTag[tag] {
+			scenario.TestCases = append(scenario.TestCases, g.testCases(op)...)
+		}
+		suite.Scenarios = append(suite.Scenarios, scenario)
+	}
+	return suite
+}
+
+// WriteSuite 将测试套件以 YAML 格式写入 w
+func WriteSuite(w io.Writer, suite *TestSuite) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(suite); err != nil {
+		return fmt.Errorf("failed to encode test suite: %w", err)
+	}
+	return encoder.Close()
+}
+
+// savedID 创建操作保存的 id 变量
+type savedID struct {
+	variable string // 变量名，例如 user_id
+	testcase string // 保存该变量的用例名
+}
+
+// suiteGenerator 生成过程中的状态
+type suiteGenerator struct {
+	spec *OpenAPISpec
+	opts GenerateOptions
+	ids  map[string]savedID // 创建操作的路径模板 -> 保存的 id 变量
+}
+
+// orderedOperations 按路径和方法排序操作：创建在前、查询和修改居中、删除最后，保证 id 先保存后使用
+func (g *suiteGenerator) orderedOperations() []*OpenAPIOperation {
+	rank := map[string]int{"POST": 0, "GET": 1, "PUT": 2, "PATCH": 3}
+	rankOf := func(op *OpenAPIOperation) int {
+		if r, ok := rank[op.Method]; ok {
+			return r
+		}
+		return len(rank)
+	}
+
+	ops := append([]*OpenAPIOperation(nil), g.spec.Operations...)
+	sort.SliceStable(ops, func(i, j int) bool {
+		a, b := ops[i], ops[j]
+		if (a.Method == "DELETE") != (b.Method == "DELETE") {
+			return b.Method == "DELETE"
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return rankOf(a) < rankOf(b)
+	})
+	return ops
+}
+
+// tagOrder 按规范顶层 tags 的声明顺序排列，未声明的 tag 按首次出现的顺序排在后面
+func (g *suiteGenerator) tagOrder(byTag map[string][]*OpenAPIOperation) []string {
+	var order []string
+	seen := make(map[string]bool)
+	if tags, ok := g.spec.doc["tags"].([]any); ok {
+		for _, t := range tags {
+			name := fmt.Sprint(g.spec.resolve(t)["name"])
+			if _, used := byTag[name]; used && !seen[name] {
+				seen[name] = true
+				order = append(order, name)
+			}
+		}
+	}
+	for _, op := range g.orderedOperations() {
+		tag := "default"
+		if len(op.Tags) > 0 {
+			tag = op.Tags[0]
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			order = append(order, tag)
+		}
+	}
+	return order
+}
+
+// tagDescription 返回规范中 tag 的描述
+func (g *suiteGenerator) tagDescription(name string) string {
+	tags, _ := g.spec.doc["tags"].([]any)
+	for _, t := range tags {
+		tag := g.spec.resolve(t)
+		if fmt.Sprint(tag["name"]) == name {
+			description, _ := tag["description"].(string)
+			return description
+		}
+	}
+	return ""
+}
+
+// testCases 为一个操作生成正向用例和反向用例
+func (g *suiteGenerator) testCases(op *OpenAPIOperation) []TestCase {
+	name := op.OperationID
+	if name == "" {
+		name = op.String()
+	}
+
+	tc := TestCase{
+		Name: name,
+		Request: RequestConfig{
+			Method: op.Method,
+			Path:   op.Path,
+		},
+		Expect: ExpectConfig{StatusCode: g.statusCode(op, "2")},
+	}
+
+	for _, param := range op.parameters {
+		in := fmt.Sprint(param["in"])
+		paramName := fmt.Sprint(param["name"])
+		required, _ := param["required"].(bool)
+
+		switch in {
+		case "path":
+			value := g.pathValue(op, paramName, param, &tc)
+			tc.Request.Path = strings.ReplaceAll(tc.Request.Path, "{"+paramName+"}", value)
+		case "query":
+			if required {
+				if tc.Request.Query == nil {
+					tc.Request.Query = make(map[string]string)
+				}
+				tc.Request.Query[paramName] = g.paramValue(param)
+			}
+		case "header":
+			if required {
+				if tc.Request.Headers == nil {
+					tc.Request.Headers = make(map[string]string)
+				}
+				tc.Request.Headers[paramName] = g.paramValue(param)
+			}
+		}
+	}
+
+	bodySchema := g.requestBodySchema(op)
+	if bodySchema != nil {
+		if body, ok := g.example(bodySchema, 0).(map[string]any); ok {
+			tc.Request.Body = body
+			if tc.Request.Headers == nil {
+				tc.Request.Headers = make(map[string]string)
+			}
+			tc.Request.Headers["Content-Type"] = "application/json"
+		}
+	}
+
+	if op.Method == "POST" {
+		if path := g.idPath(op); path != "" {
+			variable := resourceName(op.Path) + "_id"
+			tc.Save = map[string]string{variable: path}
+			g.ids[op.Path] = savedID{variable: variable, testcase: tc.Name}
+		}
+	}
+
+	cases := []TestCase{tc}
+	if g.opts.SkipNegative || tc.Request.Body == nil {
+		return cases
+	}
+	return append(cases, g.negativeCases(op, tc, g.spec.resolve(bodySchema))...)
+}
+
+// negativeCases 生成缺少必填字段和字段类型错误的反向用例
+func (g *suiteGenerator) negativeCases(op *OpenAPIOperation, base TestCase, schema map[string]any) []TestCase {
+	status := g.statusCode(op, "4")
+	if status == 0 {
+		status = 400
+	}
+
+	negative := func(name string, body map[string]any) TestCase {
+		return TestCase{
+			Name:      base.Name + " - " + name,
+			DependsOn: base.DependsOn,
+			Request: RequestConfig{
+				Method:  base.Request.Method,
+				Path:    base.Request.Path,
+				Headers: base.Request.Headers,
+				Query:   base.Request.Query,
+				Body:    body,
+			},
+			Expect: ExpectConfig{StatusCode: status},
+		}
+	}
+
+	var cases []TestCase
+	required, _ := schema["required"].([]any)
+	for _, field := range required {
+		key := fmt.Sprint(field)
+		if _, ok := base.Request.Body[key]; !ok {
+			continue
+		}
+		body := copyBody(base.Request.Body)
+		delete(body, key)
+		cases = append(cases, negative("missing "+key, body))
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	for _, key := range sortedKeys(properties) {
+		if _, ok := base.Request.Body[key]; !ok {
+			continue
+		}
+		wrong, ok := wrongTypeValue(schemaType(g.spec.resolve(properties[key])))
+		if !ok {
+			continue
+		}
+		body := copyBody(base.Request.Body)
+		body[key] = wrong
+		cases = append(cases, negative("wrong type for "+key, body))
+	}
+	return cases
+}
+
+// pathValue 返回路径参数的值：由创建操作保存的 id 使用变量引用并添加依赖，否则使用示例值
+func (g *suiteGenerator) pathValue(op *OpenAPIOperation, name string, param map[string]any, tc *TestCase) string {
+	i := strings.Index(op.Path, "{"+name+"}")
+	if i < 0 {
+		return g.paramValue(param)
+	}
+	prefix := strings.TrimSuffix(op.Path[:i], "/")
+	if id, ok := g.ids[prefix]; ok && id.testcase != tc.Name {
+		tc.DependsOn = id.testcase
+		return "{{" + id.variable + "}}"
+	}
+	return g.paramValue(param)
+}
+
+// paramValue 返回参数的示例值
+func (g *suiteGenerator) paramValue(param map[string]any) string {
+	if example, ok := param["example"]; ok {
+		return fmt.Sprint(example)
+	}
+	value := g.example(param["schema"], 0)
+	if arr, ok := value.([]any); ok {
+		parts := make([]string, len(arr))
+		for i, item := range arr {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ",")
+	}
+	if value == nil {
+		return "1"
+	}
+	return fmt.Sprint(value)
+}
+
+// statusCode 返回以 class（"2" 或 "4"）开头的最小显式响应码，没有时 2xx 默认 200，4xx 返回 0
+func (g *suiteGenerator) statusCode(op *OpenAPIOperation, class string) int {
+	for _, code := range op.ResponseCodes() {
+		if n, err := strconv.Atoi(code); err == nil && strings.HasPrefix(code, class) {
+			return n
+		}
+	}
+	if class == "2" {
+		return 200
+	}
+	return 0
+}
+
+// requestBodySchema 返回 JSON 请求体的 schema
+func (g *suiteGenerator) requestBodySchema(op *OpenAPIOperation) any {
+	body := g.spec.resolve(op.raw["requestBody"])
+	content, _ := body["content"].(map[string]any)
+	mediaType, media := matchContent(content, "")
+	if media == nil || !isJSONMediaType(mediaType) {
+		return nil
+	}
+	return media["schema"]
+}
+
+// idPath 返回创建操作成功响应中 id 字段的路径（id 或 data.id），没有时返回空字符串
+func (g *suiteGenerator) idPath(op *OpenAPIOperation) string {
+	response := op.Response(g.statusCode(op, "2"))
+	content, _ := response["content"].(map[string]any)
+	_, media := matchContent(content, "")
+	schema := g.spec.resolve(media["schema"])
+
+	for _, path := range []string{"id", "data.id"} {
+		current := schema
+		for _, key := range strings.Split(path, ".") {
+			properties, _ := current["properties"].(map[string]any)
+			current = g.spec.resolve(properties[key])
+		}
+		if current != nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// example 根据 schema 生成示例值，依次使用 example、examples、default、enum、const，否则按类型构造
+func (g *suiteGenerator) example(node any, depth int) any {
+	s := g.spec.resolve(node)
+	if s == nil || depth > 8 {
+		return nil
+	}
+
+	if v, ok := s["example"]; ok {
+		return v
+	}
+	if examples, ok := s["examples"].([]any); ok && len(examples) > 0 {
+		return examples[0]
+	}
+	if v, ok := s["default"]; ok {
+		return v
+	}
+	if enum, ok := s["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	if v, ok := s["const"]; ok {
+		return v
+	}
+
+	if allOf, ok := s["allOf"].([]any); ok {
+		merged := make(map[string]any)
+		for _, sub := range allOf {
+			if m, ok := g.example(sub, depth+1).(map[string]any); ok {
+				for k, v := range m {
+					merged[k] = v
+				}
+			}
+		}
+		if len(merged) > 0 {
+			return merged
+		}
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if subs, ok := s[key].([]any); ok && len(subs) > 0 {
+			return g.example(subs[0], depth+1)
+		}
+	}
+
+	switch schemaType(s) {
+	case "object":
+		obj := make(map[string]any)
+		properties, _ := s["properties"].(map[string]any)
+		for key, prop := range properties {
+			if readOnly, _ := g.spec.resolve(prop)["readOnly"].(bool); readOnly {
+				continue
+			}
+			if v := g.example(prop, depth+1); v != nil {
+				obj[key] = v
+			}
+		}
+		return obj
+	case "array":
+		if item := g.example(s["items"], depth+1); item != nil {
+			return []any{item}
+		}
+		return []any{}
+	case "integer":
+		if minimum, ok := toFloat64(s["minimum"]); ok {
+			return int64(minimum)
+		}
+		return int64(1)
+	case "number":
+		if minimum, ok := toFloat64(s["minimum"]); ok {
+			return minimum
+		}
+		return 1.5
+	case "boolean":
+		return true
+	case "string":
+		return stringExample(s)
+	}
+	return nil
+}
+
+// schemaType 返回 schema 的类型，type 为数组时取第一个非 null 类型，未声明时按关键字推断
+func schemaType(s map[string]any) string {
+	switch t := s["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, item := range t {
+			if name := fmt.Sprint(item); name != "null" {
+				return name
+			}
+		}
+	}
+	if _, ok := s["properties"]; ok {
+		return "object"
+	}
+	if _, ok := s["items"]; ok {
+		return "array"
+	}
+	return ""
+}
+
+// stringExample 按 format 和长度约束生成字符串示例
+func stringExample(s map[string]any) string {
+	format, _ := s["format"].(string)
+	switch format {
+	case "email":
+		return "user@example.com"
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "time":
+		return "12:00:00"
+	case "uuid":
+		return "{{uuid}}"
+	case "uri", "url":
+		return "https://example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "ipv6":
+		return "2001:db8::1"
+	}
+
+	value := "string"
+	if minLength, ok := toFloat64(s["minLength"]); ok && len(value) < int(minLength) {
+		value += strings.Repeat("x", int(minLength)-len(value))
+	}
+	if maxLength, ok := toFloat64(s["maxLength"]); ok && len(value) > int(maxLength) {
+		value = value[:int(maxLength)]
+	}
+	return value
+}
+
+// wrongTypeValue 返回与 schema 类型不符的值
+func wrongTypeValue(typ string) (any, bool) {
+	switch typ {
+	case "string":
+		return int64(12345), true
+	case "integer", "number":
+		return "not-a-number", true
+	case "boolean":
+		return "not-a-boolean", true
+	case "object":
+		return "not-an-object", true
+	case "array":
+		return "not-an-array", true
+	}
+	return nil, false
+}
+
+// resourceName 从创建操作的路径推断资源名：/users -> user，/order-items -> order_item
+func resourceName(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	name := "resource"
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i] != "" && !strings.HasPrefix(segments[i], "{") {
+			name = segments[i]
+			break
+		}
+	}
+
+	name = strings.ToLower(strings.ReplaceAll(name, "-", "_"))
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		name = strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "ses"), strings.HasSuffix(name, "xes"):
+		name = strings.TrimSuffix(name, "es")
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss"):
+		name = strings.TrimSuffix(name, "s")
+	}
+	return name
+}
+
+// copyBody 复制请求体的顶层字段
+func copyBody(body map[string]any) map[string]any {
+	c := make(map[string]any, len(body))
+	for k, v := range body {
+		c[k] = v
+	}
+	return c
+}
+
+// sortedKeys 返回排序后的 map 键
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,132 @@
+package apitest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestGenerateSuite(t *testing.T) {
+	spec, err := ParseOpenAPI([]byte(`
+openapi: 3.0.3
+info: { title: Shop, version: "1.0" }
+servers:
+  - url: http://localhost:8080/api/
+tags:
+  - { name: orders, description: Order management }
+paths:
+  /orders/{id}:
+    get:
+      tags: [orders]
+      operationId: getOrder
+      parameters:
+        - { name: id, in: path, required: true, schema: { type: integer } }
+      responses:
+        "200": { description: ok }
+    delete:
+      tags: [orders]
+      operationId: deleteOrder
+      parameters:
+        - { name: id, in: path, required: true, schema: { type: integer } }
+      responses:
+        "204": { description: deleted }
+  /orders:
+    post:
+      tags: [orders]
+      operationId: createOrder
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [sku, quantity]
+              properties:
+                sku: { type: string, example: "SKU-1" }
+                quantity: { type: integer, minimum: 1 }
+                note: { type: string, format: email }
+      responses:
+        "201":
+          description: created
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  data: { type: object, properties: { id: { type: integer } } }
+        "422": { description: invalid }
+  /health:
+    get:
+      responses:
+        default: { description: ok }
+`))
+	if err != nil {
+		t.Fatalf("ParseOpenAPI failed: %v", err)
+	}
+
+	suite := GenerateSuite(spec, GenerateOptions{})
+	if suite.Suite.Name != "Shop" || suite.Suite.BaseURL != "http://localhost:8080/api" {
+		t.Errorf("Unexpected suite config: %+v", suite.Suite)
+	}
+	if len(suite.Scenarios) != 2 || suite.Scenarios[0].Name != "orders" || suite.Scenarios[1].Name != "default" {
+		t.Fatalf("Expected orders and default scenarios, got %+v", suite.Scenarios)
+	}
+	if suite.Scenarios[0].Description != "Order management" {
+		t.Errorf("Expected tag description, got %q", suite.Scenarios[0].Description)
+	}
+
+	var names []string
+	cases := make(map[string]TestCase)
+	for _, tc := range suite.Scenarios[0].TestCases {
+		names = append(names, tc.Name)
+		cases[tc.Name] = tc
+	}
+	want := []string{
+		"createOrder",
+		"createOrder - missing sku",
+		"createOrder - missing quantity",
+		"createOrder - wrong type for note",
+		"createOrder - wrong type for quantity",
+		"createOrder - wrong type for sku",
+		"getOrder",
+		"deleteOrder",
+	}
+	if strings.Join(names, "|") != strings.Join(want, "|") {
+		t.Fatalf("Unexpected test cases:\n got %v\nwant %v", names, want)
+	}
+
+	create := cases["createOrder"]
+	if create.Expect.StatusCode != 201 || create.Save["order_id"] != "data.id" {
+		t.Errorf("Unexpected create case: %+v", create)
+	}
+	if create.Request.Body["sku"] != "SKU-1" || create.Request.Body["quantity"] != int64(1) || create.Request.Body["note"] != "user@example.com" {
+		t.Errorf("Unexpected example body: %v", create.Request.Body)
+	}
+	if status := cases["createOrder - missing sku"].Expect.StatusCode; status != 422 {
+		t.Errorf("Expected negative case to expect 422, got %d", status)
+	}
+
+	get := cases["getOrder"]
+	if get.Request.Path != "/orders/{{order_id}}" || get.DependsOn != "createOrder" {
+		t.Errorf("Expected getOrder to use saved id, got %+v", get)
+	}
+	if status := cases["deleteOrder"].Expect.StatusCode; status != 204 {
+		t.Errorf("Expected deleteOrder to expect 204, got %d", status)
+	}
+	if health := suite.Scenarios[1].TestCases[0]; health.Name != "GET /health" || health.Expect.StatusCode != 200 {
+		t.Errorf("Unexpected health case: %+v", health)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSuite(&buf, suite); err != nil {
+		t.Fatalf("WriteSuite failed: %v", err)
+	}
+	var parsed TestSuite
+	if err := yaml.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("Generated YAML does not parse: %v", err)
+	}
+	if len(parsed.Scenarios[0].TestCases) != len(want) || strings.Contains(buf.String(), "retry") {
+		t.Errorf("Unexpected generated YAML:\n%s", buf.String())
+	}
+}
@@ -5,6 +5,7 @@ go 1.25.0
 require (
 	github.com/google/uuid v1.6.0
 	github.com/yannick2025-tech/gwc-db v0.0.0-20260119143650-3ca18a729e30
+	github.com/yannick2025-tech/gwc-logging v0.0.0-20260115094859-c05c1368444e
 	github.com/yannick2025-tech/gwc-safejson v0.0.0-20260115060421-dc7b577ba002
 	gopkg.in/yaml.v3 v3.0.1
 )
@@ -16,7 +17,6 @@ require (
 	github.com/goccy/go-yaml v1.19.2 // indirect
 	github.com/golang/snappy v0.0.4 // indirect
 	github.com/syndtr/goleveldb v1.0.0 // indirect
-	github.com/yannick2025-tech/gwc-logging v0.0.0-20260115094859-c05c1368444e // indirect
 	github.com/yannick2025-tech/gwc-projectroot v0.0.0-20260115055748-2b843fc9adb7 // indirect
 	github.com/yannick2025-tech/gwc-snowflake v0.0.0-20260115070615-d3277eacac3c // indirect
 	github.com/yannick2025-tech/gwc-trace v0.0.0-20260119072657-ced174eff6c7 // indirect
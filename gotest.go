@@ -0,0 +1,234 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yannick2025-tech/gwc-db"
+)
+
+// TagSlow 带有该标签的用例在 go test -short 时跳过
+const TagSlow = "slow"
+
+// Option RunT 和 RunSuiteT 的运行选项
+type Option func(*TestRunner)
+
+// WithHandler 在进程内将请求交给 handler 处理，见 SetHandler
+func WithHandler(handler http.Handler) Option {
+	return func(r *TestRunner) { r.SetHandler(handler) }
+}
+
+// WithTransport 使用自定义的 http.RoundTripper 发送请求，见 SetTransport
+func WithTransport(rt http.RoundTripper) Option {
+	return func(r *TestRunner) { r.SetTransport(rt) }
+}
+
+// WithBaseURL 覆盖配置中的 base_url
+func WithBaseURL(url string) Option {
+	return func(r *TestRunner) { r.SetBaseURL(url) }
+}
+
+// WithVariables 覆盖或追加套件变量
+func WithVariables(vars Variables) Option {
+	return func(r *TestRunner) {
+		for k, v := range vars {
+			r.variables[k] = v
+		}
+	}
+}
+
+// WithDB 设置数据库适配器和基于软删除的清理处理器
+func WithDB(adapter db.DBAdapter) Option {
+	return func(r *TestRunner) {
+		r.dbAdapter = adapter
+		r.cleanup = NewDBCleanupHandler(adapter)
+	}
+}
+
+// WithCleanupHandler 设置 setup/teardown 中 cleanup、sql 动作的处理器
+func WithCleanupHandler(cleanup CleanupHandler) Option {
+	return func(r *TestRunner) { r.cleanup = cleanup }
+}
+
+// WithSoftAssertions 设置未配置 expect.soft 的用例是否使用 soft 断言
+func WithSoftAssertions(soft bool) Option {
+	return func(r *TestRunner) { r.SetSoftAssertions(soft) }
+}
+
+// WithHooks 注册生命周期钩子，见 AddHooks
+func WithHooks(hooks ...Hooks) Option {
+	return func(r *TestRunner) { r.AddHooks(hooks...) }
+}
+
+// WithOutput 设置运行输出的目标，默认丢弃
+func WithOutput(w io.Writer) Option {
+	return func(r *TestRunner) { r.SetOutput(w) }
+}
+
+// RunT 在 go test 中运行测试套件，返回运行器用于导出结果
+//
+// 套件、场景和用例各对应一层 t.Run 子测试（TestXxx/套件/场景/用例），因此 -run 可以按场景或用例过滤；
+// 与 Run 一样，相邻的 parallel 场景作为一批并发执行（并发数受 -parallel 限制），整批结束后才继续后面的场景，
+// 每个并发场景使用独立的变量作用域，结果按场景声明顺序记录；go test -short 时跳过带有 slow 标签的用例。
+// 失败的用例通过 t.Error 报告错误和失败的断言，setup 失败时调用 t.Fatal，teardown 在所有子测试结束后执行。
+// 被过滤或跳过的用例不会运行，依赖它们的 depends_on 用例会失败
+func RunT(t *testing.T, configPath string, opts ...Option) *TestRunner {
+	t.Helper()
+
+	runner, err := NewTestRunner(configPath, nil, nil)
+	if err != nil {
+		t.Fatalf("load suite: %v", err)
+	}
+	return runT(t, runner, opts)
+}
+
+// RunSuiteT 与 RunT 相同，但运行代码构建（例如 NewSuite）或从 embed.FS 等来源加载的套件
+// 套件引用的相对路径文件相对于当前工作目录
+func RunSuiteT(t *testing.T, suite *TestSuite, opts ...Option) *TestRunner {
+	t.Helper()
+
+	runner, err := NewTestRunnerFromSuite(suite, nil, nil)
+	if err != nil {
+		t.Fatalf("load suite: %v", err)
+	}
+	return runT(t, runner, opts)
+}
+
+// runT 应用选项并以子测试的形式运行套件
+func runT(t *testing.T, runner *TestRunner, opts []Option) *TestRunner {
+	t.Helper()
+
+	runner.SetOutput(io.Discard)
+	for _, opt := range opts {
+		opt(runner)
+	}
+
+	if err := runner.beforeSuite(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.executeSetup(t.Context()); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	defer func() {
+		if err := runner.executeTeardown(context.Background()); err != nil {
+			t.Logf("⚠️  teardown failed: %v", err)
+		}
+		runner.afterSuite(context.Background())
+	}()
+
+	t.Run(runner.suite.Suite.Name, func(t *testing.T) {
+		scenarios := runner.suite.Scenarios
+		for i := 0; i < len(scenarios); {
+			if !scenarios[i].Parallel {
+				t.Run(scenarios[i].Name, func(t *testing.T) { runner.runScenarioT(t, scenarios[i]) })
+				i++
+				continue
+			}
+			j := i
+			for j < len(scenarios) && scenarios[j].Parallel {
+				j++
+			}
+			runner.runParallelScenariosT(t, scenarios[i:j])
+			i = j
+		}
+	})
+	return runner
+}
+
+// runParallelScenariosT 并发运行一批场景的子测试，全部结束后按场景声明顺序合并输出和结果
+// 子测试不调用 t.Parallel，而是在各自的 goroutine 中调用 t.Run，保证整批结束后才运行后面的场景
+func (r *TestRunner) runParallelScenariosT(t *testing.T, scenarios []Scenario) {
+	forks := make([]*TestRunner, len(scenarios))
+	for i := range scenarios {
+		forks[i] = r.fork()
+	}
+	offset := len(r.GetResults())
+
+	slots := make(chan struct{}, testParallel())
+	var wg sync.WaitGroup
+	for i, scenario := range scenarios {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			t.Run(scenario.Name, func(t *testing.T) { forks[i].runScenarioT(t, scenario) })
+		}()
+	}
+	wg.Wait()
+
+	for _, f := range forks {
+		r.out.Write(f.out.(*bytes.Buffer).Bytes())
+		r.addResult(f.GetResults()[offset:]...)
+	}
+}
+
+// runScenarioT 将场景中的用例作为子测试运行
+func (r *TestRunner) runScenarioT(t *testing.T, scenario Scenario) {
+	r.startScenarioSessions()
+	if err := r.beforeScenario(t.Context(), scenario); err != nil {
+		t.Fatal(err)
+	}
+	var results []TestResult
+	defer func() { r.afterScenario(context.Background(), scenario, results) }()
+
+	for _, tc := range scenario.TestCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if testing.Short() && slices.Contains(tc.Tags, TagSlow) {
+				t.Skip("skipping slow test case in short mode")
+			}
+
+			result := r.runTestCase(t.Context(), scenario.Name, tc)
+			r.addResult(result)
+			results = append(results, result)
+			for _, w := range result.Warnings {
+				t.Logf("⚠️  %s", w)
+			}
+			if !result.Passed {
+				t.Error(failureDetail(result))
+			}
+		})
+	}
+}
+
+// testParallel go test -parallel 的值，无法读取时使用 GOMAXPROCS
+func testParallel() int {
+	if f := flag.Lookup("test.parallel"); f != nil {
+		if n, err := strconv.Atoi(f.Value.String()); err == nil && n > 0 {
+			return n
+		}
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// failureDetail 失败用例的错误和失败的断言
+func failureDetail(result TestResult) string {
+	var b strings.Builder
+	b.WriteString(result.Error)
+	for _, a := range result.Assertions {
+		if a.Passed {
+			continue
+		}
+		fmt.Fprintf(&b, "\n    %s %s: expected %v, got %v", a.Path, a.Operator, a.Expected, a.Actual)
+		if a.Error != "" {
+			fmt.Fprintf(&b, " (%s)", a.Error)
+		}
+	}
+	if result.Request != nil {
+		fmt.Fprintf(&b, "\n    request: %s %s", result.Request.Method, result.Request.URL)
+	}
+	if result.Response != nil {
+		fmt.Fprintf(&b, "\n    response: %d", result.Response.StatusCode)
+	}
+	return b.String()
+}
@@ -0,0 +1,148 @@
+package apitest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunT(t *testing.T) {
+	suite, err := ParseSuite([]byte(mockServerSuite))
+	if err != nil {
+		t.Fatalf("ParseSuite failed: %v", err)
+	}
+	mock := NewMockServer(suite)
+	mock.SetOutput(io.Discard)
+
+	var mu sync.Mutex
+	var seen []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		mock.ServeHTTP(w, r)
+	})
+
+	configPath := filepath.Join(t.TempDir(), "gotest.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "Users"
+  base_url: "http://api.test"
+  teardown:
+    - type: api_call
+      request: { method: "DELETE", path: "/users/{{user}}" }
+scenarios:
+  - name: "Read"
+    testcases:
+      - name: "Get User"
+        request: { method: "GET", path: "/users/{{user}}" }
+        expect: { status_code: 200 }
+        save: { user_name: "name" }
+      - name: "Slow Report"
+        tags: ["slow"]
+        request: { method: "GET", path: "/users/{{user}}" }
+        expect: { status_code: 200 }
+  - name: "Create A"
+    parallel: true
+    testcases:
+      - name: "Create"
+        request: { method: "POST", path: "/users", body: { username: "{{user_name}}" } }
+        expect: { status_code: 201 }
+  - name: "Create B"
+    parallel: true
+    testcases:
+      - name: "Reject"
+        request: { method: "POST", path: "/users", body: { other: "x" } }
+        expect: { status_code: 400 }
+`), 0644)
+
+	runner := RunT(t, configPath, WithHandler(handler), WithVariables(Variables{"user": 1}))
+
+	results := runner.GetResults()
+	want := 4
+	if testing.Short() {
+		want = 3
+	}
+	if len(results) != want {
+		t.Fatalf("Expected %d results, got %d: %+v", want, len(results), results)
+	}
+	for _, result := range results {
+		if !result.Passed {
+			t.Errorf("Test %q failed: %s", result.Name, result.Error)
+		}
+	}
+	// teardown 在 parallel 场景之后执行
+	if last := seen[len(seen)-1]; last != "DELETE /users/1" {
+		t.Errorf("Expected teardown after all subtests, got requests %v", seen)
+	}
+}
+
+func TestRunSuiteTParallelBatches(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d, err := time.ParseDuration(r.URL.Query().Get("d")); err == nil {
+			time.Sleep(d)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path": %q}`, r.URL.Path)
+	})
+
+	suite := NewSuite("Batches").
+		Scenario(
+			NewScenario("Slow").Parallel().Case(
+				NewCase("Slow Request").Get("/slow").Query("d", "50ms").ExpectStatus(200),
+			),
+			NewScenario("Fast").Parallel().Case(
+				NewCase("Fast Request").Get("/fast").ExpectStatus(200),
+			),
+			NewScenario("After Batch").Case(
+				NewCase("Depends On Slow").Get("/after").DependsOn("Slow Request").ExpectStatus(200),
+			),
+		).
+		Build()
+
+	runner := RunSuiteT(t, suite, WithHandler(handler), WithBaseURL("http://api.test"))
+
+	var names []string
+	for _, result := range runner.GetResults() {
+		names = append(names, result.Name)
+		if !result.Passed {
+			t.Errorf("Test %q failed: %s", result.Name, result.Error)
+		}
+	}
+	// 结果按场景声明顺序记录，顺序场景在整批 parallel 场景结束后运行
+	if want := []string{"Slow Request", "Fast Request", "Depends On Slow"}; !slices.Equal(names, want) {
+		t.Errorf("Expected results %v, got %v", want, names)
+	}
+}
+
+func TestFailureDetail(t *testing.T) {
+	detail := failureDetail(TestResult{
+		Error: "assertion failed",
+		Assertions: []AssertionResult{
+			{Path: "status_code", Operator: "equals", Expected: 200, Actual: 200, Passed: true},
+			{Path: "name", Operator: "equals", Expected: "John", Actual: "Jane", Error: "mismatch"},
+		},
+		Request:  &RequestData{Method: "GET", URL: "http://api.test/users/1"},
+		Response: &ResponseData{StatusCode: 200},
+	})
+
+	for _, want := range []string{"assertion failed", "name equals: expected John, got Jane (mismatch)", "GET http://api.test/users/1", "response: 200"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("Expected %q in failure detail, got:\n%s", want, detail)
+		}
+	}
+	if strings.Contains(detail, "status_code") {
+		t.Errorf("Expected passed assertions to be omitted, got:\n%s", detail)
+	}
+}
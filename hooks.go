@@ -0,0 +1,112 @@
+package apitest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Hooks 运行生命周期钩子，用于请求签名、自定义日志或额外的断言
+//
+// 多个 Hooks 按注册顺序调用。parallel 场景中的钩子会被并发调用，实现需要保证并发安全。
+// 只需要部分钩子时可以嵌入 BaseHooks
+type Hooks interface {
+	// BeforeSuite 在 setup 之前调用，返回错误时不运行套件
+	BeforeSuite(ctx context.Context, suite *TestSuite) error
+	// BeforeScenario 在场景的第一个用例之前调用，返回错误时场景中的用例都记为失败
+	BeforeScenario(ctx context.Context, scenario Scenario) error
+	// BeforeRequest 在每次发送请求之前调用（包括重试和 setup/teardown 中的 api_call），可以修改请求，返回错误时用例失败
+	BeforeRequest(ctx context.Context, req *http.Request) error
+	// AfterResponse 在用例完成（包括校验和保存变量）之后调用，可以修改结果，返回错误时用例失败
+	AfterResponse(ctx context.Context, result *TestResult) error
+	// AfterScenario 在场景的所有用例完成之后调用
+	AfterScenario(ctx context.Context, scenario Scenario, results []TestResult)
+	// AfterSuite 在 teardown 之后调用
+	AfterSuite(ctx context.Context, results []TestResult)
+}
+
+// BaseHooks 所有钩子的空实现，嵌入后只需覆盖需要的方法
+type BaseHooks struct{}
+
+// BeforeSuite 空实现
+func (BaseHooks) BeforeSuite(context.Context, *TestSuite) error { return nil }
+
+// BeforeScenario 空实现
+func (BaseHooks) BeforeScenario(context.Context, Scenario) error { return nil }
+
+// BeforeRequest 空实现
+func (BaseHooks) BeforeRequest(context.Context, *http.Request) error { return nil }
+
+// AfterResponse 空实现
+func (BaseHooks) AfterResponse(context.Context, *TestResult) error { return nil }
+
+// AfterScenario 空实现
+func (BaseHooks) AfterScenario(context.Context, Scenario, []TestResult) {}
+
+// AfterSuite 空实现
+func (BaseHooks) AfterSuite(context.Context, []TestResult) {}
+
+// AddHooks 注册生命周期钩子
+func (r *TestRunner) AddHooks(hooks ...Hooks) {
+	r.hooks = append(r.hooks, hooks...)
+}
+
+// beforeSuite 依次调用 BeforeSuite，遇到错误即停止
+func (r *TestRunner) beforeSuite(ctx context.Context) error {
+	for _, h := range r.hooks {
+		if err := h.BeforeSuite(ctx, r.suite); err != nil {
+			return fmt.Errorf("before suite hook failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// beforeScenario 依次调用 BeforeScenario，遇到错误即停止
+func (r *TestRunner) beforeScenario(ctx context.Context, scenario Scenario) error {
+	for _, h := range r.hooks {
+		if err := h.BeforeScenario(ctx, scenario); err != nil {
+			return fmt.Errorf("before scenario hook failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// beforeRequest 依次调用 BeforeRequest，遇到错误即停止
+func (r *TestRunner) beforeRequest(ctx context.Context, req *http.Request) error {
+	for _, h := range r.hooks {
+		if err := h.BeforeRequest(ctx, req); err != nil {
+			return fmt.Errorf("before request hook failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// afterResponse 依次调用 AfterResponse，钩子返回的错误记为失败的 hook 断言
+func (r *TestRunner) afterResponse(ctx context.Context, result *TestResult) {
+	for _, h := range r.hooks {
+		err := h.AfterResponse(ctx, result)
+		if err == nil {
+			continue
+		}
+		result.Assertions = append(result.Assertions, AssertionResult{Path: "hook", Operator: "hook", Passed: false, Error: err.Error()})
+		if result.Passed || result.Error == "" {
+			result.Error = fmt.Sprintf("after response hook failed: %v", err)
+		}
+		result.Passed = false
+	}
+}
+
+// afterScenario 依次调用 AfterScenario
+func (r *TestRunner) afterScenario(ctx context.Context, scenario Scenario, results []TestResult) {
+	for _, h := range r.hooks {
+		h.AfterScenario(ctx, scenario, results)
+	}
+}
+
+// afterSuite 依次调用 AfterSuite
+func (r *TestRunner) afterSuite(ctx context.Context) {
+	results := r.GetResults()
+	for _, h := range r.hooks {
+		h.AfterSuite(ctx, results)
+	}
+}
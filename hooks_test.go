@@ -0,0 +1,143 @@
+package apitest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordingHooks 记录调用顺序，为请求签名并对响应做额外检查
+type recordingHooks struct {
+	BaseHooks
+	calls []string
+}
+
+func (h *recordingHooks) BeforeSuite(_ context.Context, suite *TestSuite) error {
+	h.calls = append(h.calls, "BeforeSuite "+suite.Suite.Name)
+	return nil
+}
+
+func (h *recordingHooks) BeforeScenario(_ context.Context, scenario Scenario) error {
+	h.calls = append(h.calls, "BeforeScenario "+scenario.Name)
+	if scenario.Name == "Blocked" {
+		return errors.New("scenario disabled")
+	}
+	return nil
+}
+
+func (h *recordingHooks) BeforeRequest(_ context.Context, req *http.Request) error {
+	h.calls = append(h.calls, "BeforeRequest "+req.Method+" "+req.URL.Path)
+	if req.URL.Path == "/forbidden" {
+		return errors.New("refusing to call /forbidden")
+	}
+	req.Header.Set("X-Signature", "signed:"+req.URL.Path)
+	return nil
+}
+
+func (h *recordingHooks) AfterResponse(_ context.Context, result *TestResult) error {
+	h.calls = append(h.calls, "AfterResponse "+result.Name)
+	if result.Response != nil && result.Response.Headers["X-Legacy"] != nil {
+		return errors.New("legacy header must not be returned")
+	}
+	return nil
+}
+
+func (h *recordingHooks) AfterScenario(_ context.Context, scenario Scenario, results []TestResult) {
+	h.calls = append(h.calls, fmt.Sprintf("AfterScenario %s (%d)", scenario.Name, len(results)))
+}
+
+func (h *recordingHooks) AfterSuite(_ context.Context, results []TestResult) {
+	h.calls = append(h.calls, fmt.Sprintf("AfterSuite (%d)", len(results)))
+}
+
+func TestTestRunnerHooks(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "hooks.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "Hooked"
+  setup:
+    - type: api_call
+      request: { method: "POST", path: "/reset" }
+scenarios:
+  - name: "Signed"
+    testcases:
+      - name: "Signed Request"
+        request: { method: "GET", path: "/signed" }
+        expect: { status_code: 200 }
+      - name: "Legacy Header"
+        request: { method: "GET", path: "/legacy" }
+        expect: { status_code: 200 }
+      - name: "Forbidden"
+        request: { method: "GET", path: "/forbidden" }
+        expect: { status_code: 200 }
+  - name: "Blocked"
+    testcases:
+      - name: "Never Sent"
+        request: { method: "GET", path: "/signed" }
+        expect: { status_code: 200 }
+`), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("NewTestRunner failed: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	hooks := &recordingHooks{}
+	runner.AddHooks(hooks)
+	runner.SetHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Signature") != "signed:"+r.URL.Path {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/legacy" {
+			w.Header().Set("X-Legacy", "1")
+		}
+	}))
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	results := runner.GetResults()
+	if !results[0].Passed {
+		t.Errorf("Expected signed request to pass, got %s", results[0].Error)
+	}
+	if results[1].Passed || !strings.Contains(results[1].Error, "legacy header") {
+		t.Errorf("Expected AfterResponse error to fail the case, got %+v", results[1])
+	}
+	if last := results[1].Assertions[len(results[1].Assertions)-1]; last.Operator != "hook" || last.Passed {
+		t.Errorf("Expected failed hook assertion, got %+v", last)
+	}
+	if results[2].Passed || !strings.Contains(results[2].Error, "before request hook failed") {
+		t.Errorf("Expected BeforeRequest error to fail the case, got %+v", results[2])
+	}
+	if results[3].Passed || !strings.Contains(results[3].Error, "scenario disabled") {
+		t.Errorf("Expected BeforeScenario error to fail the scenario, got %+v", results[3])
+	}
+
+	want := []string{
+		"BeforeSuite Hooked",
+		"BeforeRequest POST /reset",
+		"BeforeScenario Signed",
+		"BeforeRequest GET /signed",
+		"AfterResponse Signed Request",
+		"BeforeRequest GET /legacy",
+		"AfterResponse Legacy Header",
+		"BeforeRequest GET /forbidden",
+		"AfterResponse Forbidden",
+		"AfterScenario Signed (3)",
+		"BeforeScenario Blocked",
+		"AfterScenario Blocked (1)",
+		"AfterSuite (4)",
+	}
+	if !reflect.DeepEqual(hooks.calls, want) {
+		t.Errorf("Unexpected hook calls:\n got %q\nwant %q", hooks.calls, want)
+	}
+}
@@ -0,0 +1,313 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// htmlReport HTML 报告模板数据
+type htmlReport struct {
+	Generated string
+	Total     int
+	Passed    int
+	Failed    int
+	Duration  string
+	Files     []htmlFile
+}
+
+// htmlFile 单个测试文件的汇总
+type htmlFile struct {
+	Name      string
+	Path      string
+	Passed    int
+	Failed    int
+	Scenarios []htmlScenario
+}
+
+// htmlScenario 单个场景的汇总
+type htmlScenario struct {
+	Name   string
+	Passed int
+	Failed int
+	Cases  []htmlCase
+}
+
+// htmlCase 单个测试用例的展示数据
+type htmlCase struct {
+	ID     string
+	File   string
+	Result TestResult
+}
+
+// ExportHTML 导出测试结果为单文件 HTML 报告
+func (r *TestRunner) ExportHTML(filepath string) error {
+	return ExportHTML(filepath, r)
+}
+
+// ExportHTML 将多个运行器的结果导出为一个单文件 HTML 报告
+func ExportHTML(path string, runners ...*TestRunner) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := WriteHTML(f, runners...); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// WriteHTML 将多个运行器的结果以 HTML 格式写入 w
+// 报告不依赖外部资源，包含按文件和场景的汇总、可筛选的用例列表以及每个用例的请求/响应详情
+func WriteHTML(w io.Writer, runners ...*TestRunner) error {
+	report := htmlReport{Generated: time.Now().Format("2006-01-02 15:04:05")}
+	var total time.Duration
+
+	for fi, r := range runners {
+		file := htmlFile{Name: r.reportName(), Path: r.configPath}
+
+		// 按场景分组，保持场景首次出现的顺序
+		index := make(map[string]int)
+		for ci, result := range r.exportedResults() {
+			i, ok := index[result.Scenario]
+			if !ok {
+				i = len(file.Scenarios)
+				index[result.Scenario] = i
+				file.Scenarios = append(file.Scenarios, htmlScenario{Name: result.Scenario})
+			}
+
+			scenario := &file.Scenarios[i]
+			scenario.Cases = append(scenario.Cases, htmlCase{
+				ID:     fmt.Sprintf("case-%d-%d", fi, ci),
+				File:   file.Name,
+				Result: result,
+			})
+			if result.Passed {
+				scenario.Passed++
+				file.Passed++
+			} else {
+				scenario.Failed++
+				file.Failed++
+			}
+			total += result.Duration
+		}
+
+		report.Files = append(report.Files, file)
+		report.Passed += file.Passed
+		report.Failed += file.Failed
+	}
+	report.Total = report.Passed + report.Failed
+	report.Duration = fmt.Sprintf("%.2fs", total.Seconds())
+
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, report); err != nil {
+		return fmt.Errorf("failed to render html report: %w", err)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// htmlJSON 将值格式化为缩进的 JSON，字符串若本身是 JSON 也会被格式化
+func htmlJSON(v any) string {
+	if s, ok := v.(string); ok {
+		var parsed any
+		if err := json.Unmarshal([]byte(s), &parsed); err != nil {
+			return s
+		}
+		v = parsed
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// htmlHeaders 将请求头/响应头格式化为按名称排序的多行文本
+func htmlHeaders(headers map[string][]string) string {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%s: %s", k, strings.Join(headers[k], ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"json":    htmlJSON,
+	"headers": htmlHeaders,
+	"seconds": func(d time.Duration) string { return fmt.Sprintf("%.2fs", d.Seconds()) },
+	"percent": func(passed, total int) string {
+		if total == 0 {
+			return "0.00%"
+		}
+		return fmt.Sprintf("%.2f%%", float64(passed)*100/float64(total))
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>API Test Report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 24px; color: #24292f; }
+h1 { margin-bottom: 4px; }
+.muted { color: #6e7781; font-size: 13px; }
+.cards { display: flex; gap: 12px; margin: 16px 0; }
+.card { border: 1px solid #d0d7de; border-radius: 6px; padding: 12px 20px; min-width: 110px; }
+.card b { display: block; font-size: 24px; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 24px; }
+th, td { border-bottom: 1px solid #d0d7de; padding: 6px 8px; text-align: left; vertical-align: top; font-size: 14px; }
+th { background: #f6f8fa; }
+.pass { color: #1a7f37; }
+.fail { color: #cf222e; }
+.warn { color: #9a6700; }
+.toolbar { margin: 12px 0; display: flex; gap: 8px; align-items: center; }
+.toolbar button { padding: 4px 12px; border: 1px solid #d0d7de; background: #f6f8fa; border-radius: 6px; cursor: pointer; }
+.toolbar button.active { background: #0969da; color: #fff; }
+.toolbar input { padding: 4px 8px; flex: 1; max-width: 320px; }
+tr.case { cursor: pointer; }
+tr.detail td { background: #fafbfc; }
+tr.detail { display: none; }
+tr.detail.open { display: table-row; }
+pre { background: #f6f8fa; padding: 8px; overflow-x: auto; margin: 4px 0 12px; font-size: 12px; white-space: pre-wrap; word-break: break-all; }
+h4 { margin: 8px 0 0; }
+</style>
+</head>
+<body>
+<h1>API Test Report</h1>
+<div class="muted">Generated at {{.Generated}}</div>
+
+<div class="cards">
+  <div class="card">Total<b>{{.Total}}</b></div>
+  <div class="card pass">Passed<b>{{.Passed}}</b></div>
+  <div class="card fail">Failed<b>{{.Failed}}</b></div>
+  <div class="card">Success Rate<b>{{percent .Passed .Total}}</b></div>
+  <div class="card">Duration<b>{{.Duration}}</b></div>
+</div>
+
+<h2>Summary</h2>
+<table>
+  <tr><th>File</th><th>Scenario</th><th>Passed</th><th>Failed</th></tr>
+  {{- range .Files}}
+  <tr><td><b>{{.Name}}</b>{{if .Path}}<div class="muted">{{.Path}}</div>{{end}}</td><td></td><td class="pass">{{.Passed}}</td><td class="fail">{{.Failed}}</td></tr>
+  {{- range .Scenarios}}
+  <tr><td></td><td>{{.Name}}</td><td class="pass">{{.Passed}}</td><td class="fail">{{.Failed}}</td></tr>
+  {{- end}}
+  {{- end}}
+</table>
+
+<h2>Test Cases</h2>
+<div class="toolbar">
+  <button data-filter="all" class="active">All</button>
+  <button data-filter="passed">Passed</button>
+  <button data-filter="failed">Failed</button>
+  <input id="search" type="search" placeholder="Filter by name, scenario or error">
+</div>
+<table id="cases">
+  <tr><th>Status</th><th>File</th><th>Scenario</th><th>Name</th><th>Duration</th><th>Error</th></tr>
+  {{- range .Files}}{{range .Scenarios}}{{range .Cases}}
+  <tr class="case" data-id="{{.ID}}" data-status="{{if .Result.Passed}}passed{{else}}failed{{end}}">
+    <td>{{if .Result.Passed}}<span class="pass">✓ PASS</span>{{else}}<span class="fail">✗ FAIL</span>{{end}}</td>
+    <td>{{.File}}</td>
+    <td>{{.Result.Scenario}}</td>
+    <td>{{.Result.Name}}</td>
+    <td>{{seconds .Result.Duration}}</td>
+    <td class="fail">{{.Result.Error}}</td>
+  </tr>
+  <tr class="detail" id="{{.ID}}">
+    <td colspan="6">
+      {{- with .Result.Request}}
+      <h4>Request{{if gt .Attempts 1}} <span class="muted">({{.Attempts}} attempts)</span>{{end}}</h4>
+      <pre>{{.Method}} {{.URL}}{{with headers .Headers}}
+{{.}}{{end}}</pre>
+      {{- if .Body}}<pre>{{json .Body}}</pre>{{end}}
+      {{- end}}
+      {{- with .Result.Response}}
+      <h4>Response</h4>
+      <pre>HTTP {{.StatusCode}}{{with headers .Headers}}
+{{.}}{{end}}</pre>
+      <pre>{{json .Body}}</pre>
+      {{- end}}
+      {{- if .Result.Assertions}}
+      <h4>Assertions</h4>
+      <table>
+        <tr><th></th><th>Path</th><th>Operator</th><th>Expected</th><th>Actual</th><th>Error</th></tr>
+        {{- range .Result.Assertions}}
+        <tr>
+          <td>{{if .Passed}}<span class="pass">✓</span>{{else}}<span class="fail">✗</span>{{end}}</td>
+          <td>{{.Path}}</td><td>{{.Operator}}</td><td>{{json .Expected}}</td><td>{{json .Actual}}</td><td class="fail">{{.Error}}</td>
+        </tr>
+        {{- end}}
+      </table>
+      {{- end}}
+      {{- if .Result.Warnings}}
+      <h4>Warnings</h4>
+      <ul>
+        {{- range .Result.Warnings}}
+        <li class="warn">{{.}}</li>
+        {{- end}}
+      </ul>
+      {{- end}}
+      {{- if .Result.Saved}}
+      <h4>Saved Variables</h4>
+      <pre>{{json .Result.Saved}}</pre>
+      {{- end}}
+    </td>
+  </tr>
+  {{- end}}{{end}}{{end}}
+</table>
+
+<script>
+(function () {
+  var filter = "all";
+  var search = document.getElementById("search");
+  var rows = document.querySelectorAll("#cases tr.case");
+
+  function apply() {
+    var q = search.value.toLowerCase();
+    rows.forEach(function (row) {
+      var show = (filter === "all" || row.dataset.status === filter) &&
+        row.textContent.toLowerCase().indexOf(q) !== -1;
+      row.style.display = show ? "" : "none";
+      if (!show) {
+        document.getElementById(row.dataset.id).classList.remove("open");
+      }
+    });
+  }
+
+  document.querySelectorAll(".toolbar button").forEach(function (btn) {
+    btn.addEventListener("click", function () {
+      document.querySelectorAll(".toolbar button").forEach(function (b) { b.classList.remove("active"); });
+      btn.classList.add("active");
+      filter = btn.dataset.filter;
+      apply();
+    });
+  });
+  search.addEventListener("input", apply);
+  rows.forEach(function (row) {
+    row.addEventListener("click", function () {
+      document.getElementById(row.dataset.id).classList.toggle("open");
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,819 @@
+package apitest
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath 编译后的 JSONPath 表达式
+//
+// 支持的语法：
+//   - $ 根节点；省略 $ 时按旧的点号路径处理，例如 data.list[0].id 等价于 $.data.list[0].id
+//   - .name、['name']、["a.b"]（键名包含点号时使用）、['a','b'] 多个键
+//   - [0]、[-1]（负数从末尾计算）、[0,2] 多个下标、[1:3]、[::2] 切片，可连续使用如 [0][1]
+//   - * 和 [*] 通配符、.. 递归下降
+//   - [?(@.status == 'active')] 过滤器，支持 == != < <= > >= =~ /regex/i && || ! 和括号，
+//     单独的 @.field 表示字段存在
+//
+// 不确定路径（包含通配符、切片、过滤器、递归下降或多个键/下标）的结果总是 []any
+type jsonPath struct {
+	expr     string
+	segments []pathSegment
+}
+
+// segmentKind 路径段类型
+type segmentKind int
+
+const (
+	segChild    segmentKind = iota // .name / ['a','b']
+	segIndex                       // [0] / [-1,2]
+	segSlice                       // [start:end:step]
+	segWildcard                    // * / [*]
+	segFilter                      // [?(...)]
+)
+
+// pathSegment 路径中的一段
+type pathSegment struct {
+	kind      segmentKind
+	recursive bool // 由 .. 引入，作用于当前节点及其所有子孙节点
+	names     []string
+	indexes   []int
+	slice     [3]*int
+	filter    filterExpr
+}
+
+// compileJSONPath 编译 JSONPath 表达式
+func compileJSONPath(expr string) (*jsonPath, error) {
+	s := strings.TrimSpace(expr)
+	p := &jsonPath{expr: expr}
+
+	switch {
+	case s == "" || s == "$":
+		return p, nil
+	case s[0] == '$':
+		s = s[1:]
+	case s[0] != '[' && s[0] != '.':
+		// 兼容旧的点号路径：data.list[0].id
+		s = "." + s
+	}
+
+	parser := &pathParser{s: s}
+	segments, err := parser.parseSegments()
+	if err != nil {
+		return nil, fmt.Errorf("invalid path '%s': %w", expr, err)
+	}
+	p.segments = segments
+	return p, nil
+}
+
+// definite 判断路径是否最多只选择一个节点
+func (p *jsonPath) definite() bool {
+	for _, seg := range p.segments {
+		if seg.recursive {
+			return false
+		}
+		switch seg.kind {
+		case segChild:
+			if len(seg.names) != 1 {
+				return false
+			}
+		case segIndex:
+			if len(seg.indexes) != 1 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// nodes 返回路径在 root 上选中的所有节点
+func (p *jsonPath) nodes(root any) []any {
+	current := []any{root}
+	for _, seg := range p.segments {
+		var next []any
+		for _, node := range current {
+			if seg.recursive {
+				for _, d := range descendants(node) {
+					next = append(next, seg.apply(d, root)...)
+				}
+			} else {
+				next = append(next, seg.apply(node, root)...)
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// lookup 在 data 上求值：确定路径返回单个值，不确定路径返回 []any
+// found 表示路径是否选中了节点，用于区分字段缺失和值为 null
+func (p *jsonPath) lookup(data any) (value any, found bool) {
+	nodes := p.nodes(data)
+	if p.definite() {
+		if len(nodes) == 0 {
+			return nil, false
+		}
+		return nodes[0], true
+	}
+	if nodes == nil {
+		nodes = []any{}
+	}
+	return nodes, len(nodes) > 0
+}
+
+// resolvePath 编译并在 data 上求值路径
+func resolvePath(path string, data any) (value any, found bool, err error) {
+	p, err := compileJSONPath(path)
+	if err != nil {
+		return nil, false, err
+	}
+	value, found = p.lookup(data)
+	return value, found, nil
+}
+
+// apply 将路径段作用于单个节点
+func (seg pathSegment) apply(node, root any) []any {
+	var out []any
+	switch seg.kind {
+	case segChild:
+		switch v := node.(type) {
+		case map[string]any:
+			for _, name := range seg.names {
+				if child, ok := v[name]; ok {
+					out = append(out, child)
+				}
+			}
+		case []any:
+			// 宽松处理：data.0 视为 data[0]
+			for _, name := range seg.names {
+				if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < len(v) {
+					out = append(out, v[i])
+				}
+			}
+		}
+	case segIndex:
+		if arr, ok := node.([]any); ok {
+			for _, i := range seg.indexes {
+				if i < 0 {
+					i += len(arr)
+				}
+				if i >= 0 && i < len(arr) {
+					out = append(out, arr[i])
+				}
+			}
+		}
+	case segSlice:
+		if arr, ok := node.([]any); ok {
+			out = sliceArray(arr, seg.slice)
+		}
+	case segWildcard:
+		out = children(node)
+	case segFilter:
+		for _, child := range children(node) {
+			if truthy(seg.filter.eval(child, root)) {
+				out = append(out, child)
+			}
+		}
+	}
+	return out
+}
+
+// children 返回数组元素或对象的值（按键名排序，保证结果稳定）
+func children(node any) []any {
+	switch v := node.(type) {
+	case []any:
+		return v
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make([]any, 0, len(v))
+		for _, k := range keys {
+			out = append(out, v[k])
+		}
+		return out
+	}
+	return nil
+}
+
+// descendants 返回节点本身及其所有子孙节点（深度优先）
+func descendants(node any) []any {
+	out := []any{node}
+	for _, child := range children(node) {
+		out = append(out, descendants(child)...)
+	}
+	return out
+}
+
+// sliceArray 按 [start:end:step] 切片数组，语义与 Python 相同
+func sliceArray(arr []any, bounds [3]*int) []any {
+	n := len(arr)
+	step := 1
+	if bounds[2] != nil {
+		step = *bounds[2]
+	}
+	if step == 0 {
+		return nil
+	}
+
+	normalize := func(i int) int {
+		if i < 0 {
+			return i + n
+		}
+		return i
+	}
+
+	var out []any
+	if step > 0 {
+		start, end := 0, n
+		if bounds[0] != nil {
+			start = min(max(normalize(*bounds[0]), 0), n)
+		}
+		if bounds[1] != nil {
+			end = min(max(normalize(*bounds[1]), 0), n)
+		}
+		for i := start; i < end; i += step {
+			out = append(out, arr[i])
+		}
+	} else {
+		start, end := n-1, -1
+		if bounds[0] != nil {
+			start = min(max(normalize(*bounds[0]), -1), n-1)
+		}
+		if bounds[1] != nil {
+			end = min(max(normalize(*bounds[1]), -1), n-1)
+		}
+		for i := start; i > end; i += step {
+			out = append(out, arr[i])
+		}
+	}
+	return out
+}
+
+// pathParser 路径解析器
+type pathParser struct {
+	s   string
+	pos int
+}
+
+// parseSegments 解析所有路径段
+func (p *pathParser) parseSegments() ([]pathSegment, error) {
+	var segments []pathSegment
+	for p.pos < len(p.s) {
+		recursive := false
+		switch {
+		case strings.HasPrefix(p.s[p.pos:], ".."):
+			recursive = true
+			p.pos += 2
+		case p.s[p.pos] == '.':
+			p.pos++
+		case p.s[p.pos] == '[':
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' at %d", p.s[p.pos], p.pos)
+		}
+
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("path ends unexpectedly")
+		}
+
+		var seg pathSegment
+		var err error
+		switch p.s[p.pos] {
+		case '[':
+			seg, err = p.parseBracket()
+		case '*':
+			p.pos++
+			seg = pathSegment{kind: segWildcard}
+		default:
+			seg, err = p.parseName()
+		}
+		if err != nil {
+			return nil, err
+		}
+		seg.recursive = recursive
+		segments = append(segments, seg)
+	}
+	return segments, nil
+}
+
+// parseName 解析点号后的键名
+func (p *pathParser) parseName() (pathSegment, error) {
+	start := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] != '.' && p.s[p.pos] != '[' {
+		p.pos++
+	}
+	name := strings.TrimSpace(p.s[start:p.pos])
+	if name == "" {
+		return pathSegment{}, fmt.Errorf("empty key at %d", start)
+	}
+	return pathSegment{kind: segChild, names: []string{name}}, nil
+}
+
+// parseBracket 解析 [...] 中的内容
+func (p *pathParser) parseBracket() (pathSegment, error) {
+	start := p.pos
+	end, err := matchBracket(p.s, p.pos)
+	if err != nil {
+		return pathSegment{}, err
+	}
+	content := strings.TrimSpace(p.s[start+1 : end])
+	p.pos = end + 1
+
+	switch {
+	case content == "":
+		return pathSegment{}, fmt.Errorf("empty brackets at %d", start)
+	case content == "*":
+		return pathSegment{kind: segWildcard}, nil
+	case content[0] == '?':
+		filter, err := parseFilter(strings.TrimSpace(content[1:]))
+		if err != nil {
+			return pathSegment{}, err
+		}
+		return pathSegment{kind: segFilter, filter: filter}, nil
+	case content[0] == '\'' || content[0] == '"':
+		var names []string
+		for _, part := range splitTopLevel(content, ',') {
+			name, err := unquote(strings.TrimSpace(part))
+			if err != nil {
+				return pathSegment{}, err
+			}
+			names = append(names, name)
+		}
+		return pathSegment{kind: segChild, names: names}, nil
+	case strings.Contains(content, ":"):
+		parts := strings.Split(content, ":")
+		if len(parts) > 3 {
+			return pathSegment{}, fmt.Errorf("invalid slice '%s'", content)
+		}
+		seg := pathSegment{kind: segSlice}
+		for i, part := range parts {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return pathSegment{}, fmt.Errorf("invalid slice '%s'", content)
+			}
+			seg.slice[i] = &n
+		}
+		return seg, nil
+	default:
+		seg := pathSegment{kind: segIndex}
+		for _, part := range strings.Split(content, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return pathSegment{}, fmt.Errorf("invalid index '%s'", content)
+			}
+			seg.indexes = append(seg.indexes, n)
+		}
+		return seg, nil
+	}
+}
+
+// matchBracket 返回与 s[open] 处的 [ 匹配的 ] 的位置，忽略引号、正则和括号内的内容
+func matchBracket(s string, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'', '"':
+			j := skipQuoted(s, i)
+			if j < 0 {
+				return 0, fmt.Errorf("unterminated string at %d", i)
+			}
+			i = j
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+			if depth == 0 {
+				if c != ']' {
+					return 0, fmt.Errorf("mismatched ')' at %d", i)
+				}
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unterminated '[' at %d", open)
+}
+
+// skipQuoted 返回从 s[i] 开始的引号字符串结束引号的位置，未闭合时返回 -1
+func skipQuoted(s string, i int) int {
+	quote := s[i]
+	for j := i + 1; j < len(s); j++ {
+		if s[j] == '\\' {
+			j++
+			continue
+		}
+		if s[j] == quote {
+			return j
+		}
+	}
+	return -1
+}
+
+// splitTopLevel 按 sep 拆分字符串，忽略引号内的分隔符
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'', '"':
+			if j := skipQuoted(s, i); j > 0 {
+				i = j
+			}
+		case sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquote 去掉单引号或双引号并处理转义
+func unquote(s string) (string, error) {
+	if len(s) < 2 || (s[0] != '\'' && s[0] != '"') || s[len(s)-1] != s[0] {
+		return "", fmt.Errorf("invalid quoted string %s", s)
+	}
+	var b strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] == '\\' && i+1 < len(s)-1 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String(), nil
+}
+
+// filterExpr 过滤器表达式
+type filterExpr interface {
+	// eval 在当前节点 current 上求值，root 用于 $ 开头的路径
+	eval(current, root any) filterValue
+}
+
+// filterValue 过滤器表达式的值，found 为 false 表示路径没有选中节点
+type filterValue struct {
+	value any
+	found bool
+}
+
+// truthy 判断过滤器结果是否为真：路径存在即为真，字面量按布尔值判断
+func truthy(v filterValue) bool {
+	if !v.found {
+		return false
+	}
+	if b, ok := v.value.(bool); ok {
+		return b
+	}
+	return true
+}
+
+// filterLiteral 字面量
+type filterLiteral struct{ value any }
+
+func (l filterLiteral) eval(_, _ any) filterValue {
+	return filterValue{value: l.value, found: true}
+}
+
+// filterPath @ 或 $ 开头的路径
+type filterPath struct {
+	path     *jsonPath
+	fromRoot bool
+}
+
+func (f filterPath) eval(current, root any) filterValue {
+	node := current
+	if f.fromRoot {
+		node = root
+	}
+	value, found := f.path.lookup(node)
+	return filterValue{value: value, found: found}
+}
+
+// filterNot 逻辑非
+type filterNot struct{ expr filterExpr }
+
+func (f filterNot) eval(current, root any) filterValue {
+	return filterValue{value: !truthy(f.expr.eval(current, root)), found: true}
+}
+
+// filterLogical 逻辑与/或
+type filterLogical struct {
+	op          string
+	left, right filterExpr
+}
+
+func (f filterLogical) eval(current, root any) filterValue {
+	left := truthy(f.left.eval(current, root))
+	var result bool
+	if f.op == "&&" {
+		result = left && truthy(f.right.eval(current, root))
+	} else {
+		result = left || truthy(f.right.eval(current, root))
+	}
+	return filterValue{value: result, found: true}
+}
+
+// filterCompare 比较表达式
+type filterCompare struct {
+	op          string
+	left, right filterExpr
+}
+
+func (f filterCompare) eval(current, root any) filterValue {
+	left := f.left.eval(current, root)
+	right := f.right.eval(current, root)
+	return filterValue{value: compareFilterValues(f.op, left, right), found: true}
+}
+
+// filterRegex 正则匹配 =~ /pattern/
+type filterRegex struct {
+	left filterExpr
+	re   *regexp.Regexp
+}
+
+func (f filterRegex) eval(current, root any) filterValue {
+	left := f.left.eval(current, root)
+	s, ok := left.value.(string)
+	return filterValue{value: left.found && ok && f.re.MatchString(s), found: true}
+}
+
+// compareFilterValues 比较两个过滤器值
+func compareFilterValues(op string, left, right filterValue) bool {
+	switch op {
+	case "==":
+		return left.found == right.found && (!left.found || filterEqual(left.value, right.value))
+	case "!=":
+		return !compareFilterValues("==", left, right)
+	}
+
+	if !left.found || !right.found {
+		return false
+	}
+
+	var cmp int
+	if a, ok := toFloat64(left.value); ok {
+		b, ok := toFloat64(right.value)
+		if !ok {
+			return false
+		}
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		}
+	} else if a, ok := left.value.(string); ok {
+		b, ok := right.value.(string)
+		if !ok {
+			return false
+		}
+		cmp = strings.Compare(a, b)
+	} else {
+		return false
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// filterEqual 判断两个 JSON 值是否相等，数字按数值比较
+func filterEqual(a, b any) bool {
+	if af, ok := toFloat64(a); ok {
+		bf, ok := toFloat64(b)
+		return ok && af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// filterParser 过滤器表达式解析器
+type filterParser struct {
+	s   string
+	pos int
+}
+
+// parseFilter 解析过滤器表达式
+func parseFilter(s string) (filterExpr, error) {
+	p := &filterParser{s: s}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.s) {
+		return nil, fmt.Errorf("unexpected '%s' in filter", p.s[p.pos:])
+	}
+	return expr, nil
+}
+
+func (p *filterParser) skipSpaces() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// consume 跳过空白后尝试匹配 token
+func (p *filterParser) consume(token string) bool {
+	p.skipSpaces()
+	if strings.HasPrefix(p.s[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = filterLogical{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = filterLogical{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterExpr, error) {
+	p.skipSpaces()
+	if strings.HasPrefix(p.s[p.pos:], "!") && !strings.HasPrefix(p.s[p.pos:], "!=") {
+		p.pos++
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return filterNot{expr: expr}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (filterExpr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.consume("=~") {
+		p.skipSpaces()
+		re, err := p.parseRegex()
+		if err != nil {
+			return nil, err
+		}
+		return filterRegex{left: left, re: re}, nil
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			right, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			return filterCompare{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+// parseOperand 解析括号表达式、路径或字面量
+func (p *filterParser) parseOperand() (filterExpr, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.s) {
+		return nil, fmt.Errorf("filter ends unexpectedly")
+	}
+
+	switch c := p.s[p.pos]; {
+	case c == '(':
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, fmt.Errorf("missing ')' in filter")
+		}
+		return expr, nil
+	case c == '@' || c == '$':
+		return p.parsePath()
+	case c == '\'' || c == '"':
+		end := skipQuoted(p.s, p.pos)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string in filter")
+		}
+		value, err := unquote(p.s[p.pos : end+1])
+		if err != nil {
+			return nil, err
+		}
+		p.pos = end + 1
+		return filterLiteral{value: value}, nil
+	default:
+		start := p.pos
+		for p.pos < len(p.s) && !strings.ContainsRune(" )=!<>&|", rune(p.s[p.pos])) {
+			p.pos++
+		}
+		word := p.s[start:p.pos]
+		switch word {
+		case "true":
+			return filterLiteral{value: true}, nil
+		case "false":
+			return filterLiteral{value: false}, nil
+		case "null":
+			return filterLiteral{value: nil}, nil
+		}
+		if num, ok := parseNumber(word); ok && isNumericString(word) {
+			return filterLiteral{value: num}, nil
+		}
+		return nil, fmt.Errorf("unexpected '%s' in filter", word)
+	}
+}
+
+// parsePath 解析过滤器中的 @ 或 $ 路径
+func (p *filterParser) parsePath() (filterExpr, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if c == '[' {
+			end, err := matchBracket(p.s, p.pos)
+			if err != nil {
+				return nil, err
+			}
+			p.pos = end + 1
+			continue
+		}
+		if strings.ContainsRune(" )=!<>&|", rune(c)) {
+			break
+		}
+		p.pos++
+	}
+
+	expr := p.s[start:p.pos]
+	path, err := compileJSONPath("$" + expr[1:])
+	if err != nil {
+		return nil, err
+	}
+	return filterPath{path: path, fromRoot: expr[0] == '$'}, nil
+}
+
+// parseRegex 解析 /pattern/flags 形式的正则
+func (p *filterParser) parseRegex() (*regexp.Regexp, error) {
+	if p.pos >= len(p.s) || p.s[p.pos] != '/' {
+		return nil, fmt.Errorf("expected /regex/ after =~")
+	}
+	end := -1
+	for i := p.pos + 1; i < len(p.s); i++ {
+		if p.s[i] == '\\' {
+			i++
+			continue
+		}
+		if p.s[i] == '/' {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated regex in filter")
+	}
+
+	pattern := p.s[p.pos+1 : end]
+	p.pos = end + 1
+	if p.pos < len(p.s) && p.s[p.pos] == 'i' {
+		pattern = "(?i)" + pattern
+		p.pos++
+	}
+	return regexp.Compile(pattern)
+}
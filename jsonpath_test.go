@@ -0,0 +1,90 @@
+package apitest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	data, err := decodeJSON([]byte(`{
+		"code": 0,
+		"data": {
+			"matrix": [[1, 2], [3, 4]],
+			"items": [
+				{"id": 1, "status": "active", "price": 10, "tags": ["a"]},
+				{"id": 2, "status": "inactive", "price": 25},
+				{"id": 3, "status": "active", "price": 40, "tags": []}
+			],
+			"user.name": "dotted",
+			"big": 1234567890123456789
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("decodeJSON failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want any
+	}{
+		{"code", int64(0)},
+		{"data.items[0].id", int64(1)},
+		{"$.data.items[-1].id", int64(3)},
+		{"data.matrix[1][0]", int64(3)},
+		{"$.data['user.name']", "dotted"},
+		{`$["data"]["big"]`, int64(1234567890123456789)},
+		{"$.data.items[*].id", []any{int64(1), int64(2), int64(3)}},
+		{"$.data.items[?(@.status=='active')].id", []any{int64(1), int64(3)}},
+		{"$.data.items[?(@.price > 15 && @.status != 'inactive')].id", []any{int64(3)}},
+		{"$.data.items[?(@.tags)].id", []any{int64(1), int64(3)}},
+		{"$.data.items[?(!@.tags)].id", []any{int64(2)}},
+		{"$.data.items[?(@.status =~ /^ACT/i)].id", []any{int64(1), int64(3)}},
+		{"$.data.items[0:2].id", []any{int64(1), int64(2)}},
+		{"$.data.items[::-1].id", []any{int64(3), int64(2), int64(1)}},
+		{"$..id", []any{int64(1), int64(2), int64(3)}},
+		{"$.data.items[0,2].price", []any{int64(10), int64(40)}},
+		{"data.missing", nil},
+		{"data.items[5].id", nil},
+	}
+
+	for _, tt := range tests {
+		got, _, err := resolvePath(tt.path, data)
+		if err != nil {
+			t.Errorf("resolvePath(%q) returned error: %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("resolvePath(%q) = %#v, want %#v", tt.path, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"$.data[", "$.items[?(@.a ==)]", "$.a[x]"} {
+		if _, _, err := resolvePath(invalid, data); err == nil {
+			t.Errorf("resolvePath(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestJSONPathTopLevelArray(t *testing.T) {
+	data, err := decodeJSON([]byte(`[{"id": 7, "name": "x"}, {"id": 8, "name": "y"}]`))
+	if err != nil {
+		t.Fatalf("decodeJSON failed: %v", err)
+	}
+
+	runner := &TestRunner{variables: Variables{}}
+	assertions := []Assertion{
+		{Path: "$[0].id", Operator: "equals", Value: 7},
+		{Path: "$[*].name", Operator: "contains", Value: "y"},
+		{Path: "$", Operator: "length", Value: 2},
+		{Path: "$[?(@.id > 7)]", Operator: "length", Value: 1},
+	}
+	for _, a := range assertions {
+		if err := runner.executeAssertion(a, data); err != nil {
+			t.Errorf("Assertion %+v failed: %v", a, err)
+		}
+	}
+
+	if err := runner.executeAssertion(Assertion{Path: "$[*].name", Operator: "contains", Value: "z"}, data); err == nil {
+		t.Error("contains should fail when no element matches")
+	}
+}
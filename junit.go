@@ -0,0 +1,144 @@
+package apitest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// junitTestSuites JUnit 报告根节点
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite 对应一个 YAML 测试文件
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	File      string          `xml:"file,attr,omitempty"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase 对应一个测试用例，classname 为场景名
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// junitFailure 失败信息
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// ExportJUnit 导出测试结果为 JUnit XML
+func (r *TestRunner) ExportJUnit(filepath string) error {
+	return ExportJUnit(filepath, r)
+}
+
+// ExportJUnit 将多个运行器的结果导出到同一个 JUnit XML 文件，每个运行器对应一个 <testsuite>
+func ExportJUnit(path string, runners ...*TestRunner) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := WriteJUnit(f, runners...); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// WriteJUnit 将多个运行器的结果以 JUnit XML 格式写入 w
+func WriteJUnit(w io.Writer, runners ...*TestRunner) error {
+	report := junitTestSuites{}
+	var total time.Duration
+	timestamp := time.Now().Format("2006-01-02T15:04:05")
+
+	for _, r := range runners {
+		suite := junitTestSuite{
+			Name:      r.reportName(),
+			File:      r.configPath,
+			Timestamp: timestamp,
+		}
+
+		var suiteDuration time.Duration
+		for _, result := range r.exportedResults() {
+			tc := junitTestCase{
+				Name:      result.Name,
+				Classname: result.Scenario,
+				Time:      junitSeconds(result.Duration),
+			}
+			if !result.Passed {
+				tc.Failure = &junitFailure{
+					Message: result.Error,
+					Type:    "AssertionError",
+					Text:    result.Error,
+				}
+				suite.Failures++
+			}
+			if result.Response != nil {
+				data, err := json.MarshalIndent(result.Response, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode response of '%s': %w", result.Name, err)
+				}
+				tc.SystemOut = string(data)
+			}
+
+			suite.TestCases = append(suite.TestCases, tc)
+			suite.Tests++
+			suiteDuration += result.Duration
+		}
+		suite.Time = junitSeconds(suiteDuration)
+
+		report.Suites = append(report.Suites, suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		total += suiteDuration
+	}
+	report.Time = junitSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to encode junit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// reportName 报告中使用的套件名：优先使用配置中的 name，否则使用文件名
+func (r *TestRunner) reportName() string {
+	if r.suite != nil && r.suite.Suite.Name != "" {
+		return r.suite.Suite.Name
+	}
+	if r.configPath != "" {
+		return strings.TrimSuffix(filepath.Base(r.configPath), filepath.Ext(r.configPath))
+	}
+	return "apitest"
+}
+
+// junitSeconds 将耗时格式化为 JUnit 使用的秒数
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,63 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	runner := &TestRunner{
+		suite:      &TestSuite{Suite: SuiteConfig{Name: "User API"}},
+		configPath: "testcases/user_api_test.yaml",
+		results: []TestResult{
+			{Scenario: "Lifecycle", Name: "Create User", Passed: true, Duration: 120 * time.Millisecond,
+				Response: &ResponseData{StatusCode: 201, Body: map[string]any{"id": 1}}},
+			{Scenario: "Lifecycle", Name: "Delete User", Passed: false, Duration: 50 * time.Millisecond,
+				Error: "status code mismatch: expected 204, got 500"},
+		},
+	}
+	unnamed := &TestRunner{
+		suite:      &TestSuite{},
+		configPath: "testcases/order_test.yaml",
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, runner, unnamed); err != nil {
+		t.Fatalf("WriteJUnit failed: %v", err)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Generated report is not valid XML: %v\n%s", err, buf.String())
+	}
+
+	if report.Tests != 2 || report.Failures != 1 {
+		t.Errorf("Expected 2 tests and 1 failure, got %d tests and %d failures", report.Tests, report.Failures)
+	}
+	if len(report.Suites) != 2 {
+		t.Fatalf("Expected 2 testsuites, got %d", len(report.Suites))
+	}
+	if report.Suites[1].Name != "order_test" {
+		t.Errorf("Expected file name fallback 'order_test', got '%s'", report.Suites[1].Name)
+	}
+
+	suite := report.Suites[0]
+	if suite.Name != "User API" || suite.File != "testcases/user_api_test.yaml" {
+		t.Errorf("Unexpected testsuite attributes: name=%s file=%s", suite.Name, suite.File)
+	}
+	if suite.TestCases[0].Classname != "Lifecycle" || suite.TestCases[0].Time != "0.120" {
+		t.Errorf("Unexpected testcase attributes: %+v", suite.TestCases[0])
+	}
+	if !strings.Contains(suite.TestCases[0].SystemOut, `"status_code": 201`) {
+		t.Errorf("Expected response data in system-out, got: %s", suite.TestCases[0].SystemOut)
+	}
+	if suite.TestCases[0].Failure != nil {
+		t.Error("Passed test case should not have a failure")
+	}
+	if f := suite.TestCases[1].Failure; f == nil || f.Message != "status code mismatch: expected 204, got 500" {
+		t.Errorf("Expected failure message from TestResult.Error, got %+v", f)
+	}
+}
@@ -0,0 +1,439 @@
+package apitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Mock 模拟接口：请求匹配时返回 Response
+type Mock struct {
+	Name     string       `yaml:"name,omitempty"`
+	Request  MockRequest  `yaml:"request"`
+	Response MockResponse `yaml:"response"`
+}
+
+// MockRequest 请求匹配条件
+// 路径支持 {name} 路径参数和匹配任意一段的 *；query、headers、body 只需包含声明的字段，
+// 值为 "*" 或包含 {{...}} 时只要求字段存在
+type MockRequest struct {
+	Method  string            `yaml:"method,omitempty"` // 为空时匹配任意方法
+	Path    string            `yaml:"path"`
+	Query   map[string]string `yaml:"query,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    map[string]any    `yaml:"body,omitempty"`
+}
+
+// MockResponse 模拟响应
+// 字符串中的 {{uuid}}、套件变量、{{path.name}}、{{query.name}}、{{header.Name}}、{{body.path}} 会被替换，
+// 整个字符串只有一个引用时保留原值的类型
+type MockResponse struct {
+	Status  int               `yaml:"status,omitempty"` // 默认 200
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    any               `yaml:"body,omitempty"`  // 对象或数组按 JSON 返回，字符串原样返回
+	Delay   int               `yaml:"delay,omitempty"` // 毫秒
+}
+
+// MockServer 按测试套件中的 mocks 和测试用例提供模拟接口
+type MockServer struct {
+	mocks     []*compiledMock
+	variables Variables
+	out       io.Writer
+	mu        sync.Mutex // 保护 out
+}
+
+// compiledMock 编译后的模拟接口
+type compiledMock struct {
+	Mock
+	segments []string // 路径分段：字面量、{name} 参数或 * 通配
+}
+
+// NewMockServer 根据一个或多个测试套件创建模拟服务
+//
+// 先按声明顺序匹配 mocks 中的接口，再匹配由测试用例推导出的接口：请求取自 request（路径中的 {{变量}}
+// 匹配任意一段），响应状态码取 expect.status_code，响应体由 expect.response_body、equals 断言和 save
+// 的路径组成（save 的字段默认返回 {{uuid}}），因此套件可以直接在自己的模拟服务上运行。
+// 同一组内越具体（字面量路径段、匹配条件越多）的接口越先匹配
+func NewMockServer(suites ...*TestSuite) *MockServer {
+	m := &MockServer{variables: Variables{}, out: os.Stdout}
+
+	var explicit, derived []*compiledMock
+	for _, suite := range suites {
+		for k, v := range suite.Variables {
+			m.variables[k] = v
+		}
+		for _, mock := range suite.Mocks {
+			explicit = append(explicit, compileMock(mock))
+		}
+		for _, scenario := range suite.Scenarios {
+			for _, tc := range scenario.TestCases {
+				derived = append(derived, compileMock(mockFromTestCase(tc)))
+			}
+		}
+	}
+
+	for _, group := range [][]*compiledMock{explicit, derived} {
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].specificity() > group[j].specificity()
+		})
+		m.mocks = append(m.mocks, group...)
+	}
+	return m
+}
+
+// SetOutput 设置请求日志的输出，默认 os.Stdout
+func (m *MockServer) SetOutput(w io.Writer) {
+	m.out = w
+}
+
+// ServeHTTP 返回第一个匹配的模拟响应，没有匹配时返回 404
+func (m *MockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body := mockRequestBody(r)
+
+	for _, mock := range m.mocks {
+		params, ok := mock.match(r, body)
+		if !ok {
+			continue
+		}
+
+		resp := mock.Response
+		status := resp.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if resp.Delay > 0 {
+			time.Sleep(time.Duration(resp.Delay) * time.Millisecond)
+		}
+
+		render := func(s string) any { return m.render(s, r, params, body) }
+		for k, v := range resp.Headers {
+			w.Header().Set(k, fmt.Sprint(render(v)))
+		}
+
+		var payload []byte
+		switch b := resp.Body.(type) {
+		case nil:
+		case string:
+			payload = []byte(fmt.Sprint(render(b)))
+			if w.Header().Get("Content-Type") == "" {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			}
+		default:
+			payload, _ = json.Marshal(renderValue(b, render))
+			if w.Header().Get("Content-Type") == "" {
+				w.Header().Set("Content-Type", "application/json")
+			}
+		}
+
+		w.WriteHeader(status)
+		w.Write(payload)
+		m.log("🎭 %s %s → %d (%s)\n", r.Method, r.URL.RequestURI(), status, mock.label())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("no mock matches %s %s", r.Method, r.URL.Path)})
+	m.log("🎭 %s %s → 404 (no mock)\n", r.Method, r.URL.RequestURI())
+}
+
+// log 输出请求日志，并发请求时保证每行完整
+func (m *MockServer) log(format string, args ...any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fmt.Fprintf(m.out, format, args...)
+}
+
+// mockTemplate 匹配响应中的 {{...}} 引用
+var mockTemplate = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+// render 替换字符串中的引用，整个字符串只有一个引用时返回原值
+func (m *MockServer) render(s string, r *http.Request, params map[string]string, body any) any {
+	lookup := func(name string) (any, bool) {
+		scope, key, _ := strings.Cut(name, ".")
+		switch {
+		case name == "uuid":
+			return uuid.New().String(), true
+		case scope == "path" && key != "":
+			v, ok := params[key]
+			return v, ok
+		case scope == "query" && key != "":
+			return r.URL.Query().Get(key), r.URL.Query().Has(key)
+		case scope == "header" && key != "":
+			return r.Header.Get(key), r.Header.Get(key) != ""
+		case scope == "body" && key != "":
+			v, found, err := resolvePath(key, body)
+			return v, found && err == nil
+		}
+		v, ok := m.variables[name]
+		return v, ok
+	}
+
+	if match := mockTemplate.FindStringSubmatch(s); match != nil && match[0] == s {
+		if v, ok := lookup(match[1]); ok {
+			return v
+		}
+		return s
+	}
+	return mockTemplate.ReplaceAllStringFunc(s, func(ref string) string {
+		if v, ok := lookup(mockTemplate.FindStringSubmatch(ref)[1]); ok {
+			return fmt.Sprint(v)
+		}
+		return ref
+	})
+}
+
+// renderValue 递归替换响应体中的字符串
+func renderValue(v any, render func(string) any) any {
+	switch val := v.(type) {
+	case string:
+		return render(val)
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = renderValue(item, render)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = renderValue(item, render)
+		}
+		return out
+	}
+	return v
+}
+
+// compileMock 编译路径模板，并预先规范化请求体和响应体，之后并发处理请求时只读不写
+func compileMock(mock Mock) *compiledMock {
+	mock.Request.Body = normalizeMap(mock.Request.Body)
+	mock.Response.Body = normalizeYAML(mock.Response.Body)
+	return &compiledMock{
+		Mock:     mock,
+		segments: strings.Split(strings.Trim(mock.Request.Path, "/"), "/"),
+	}
+}
+
+// label 日志中使用的名称
+func (c *compiledMock) label() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return strings.TrimSpace(c.Request.Method + " " + c.Request.Path)
+}
+
+// specificity 字面量路径段和匹配条件越多越具体
+func (c *compiledMock) specificity() int {
+	score := 0
+	for _, segment := range c.segments {
+		if mockWildcard(segment) == "" {
+			score += 100
+		}
+	}
+	if c.Request.Method != "" {
+		score += 10
+	}
+	return score + len(c.Request.Query) + len(c.Request.Headers) + countFields(c.Request.Body)
+}
+
+// mockWildcard 判断路径段是否为通配：返回参数名，* 和 {{变量}} 返回 "*"，字面量返回空字符串
+func mockWildcard(segment string) string {
+	switch {
+	case segment == "*", strings.HasPrefix(segment, "{{") && strings.HasSuffix(segment, "}}"):
+		return "*"
+	case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+		return segment[1 : len(segment)-1]
+	}
+	return ""
+}
+
+// match 判断请求是否匹配，返回路径参数
+func (c *compiledMock) match(r *http.Request, body any) (map[string]string, bool) {
+	if c.Request.Method != "" && !strings.EqualFold(c.Request.Method, r.Method) {
+		return nil, false
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) != len(c.segments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, pattern := range c.segments {
+		switch name := mockWildcard(pattern); name {
+		case "":
+			if pattern != segments[i] {
+				return nil, false
+			}
+		case "*":
+		default:
+			params[name] = segments[i]
+		}
+	}
+
+	query := r.URL.Query()
+	for k, v := range c.Request.Query {
+		if !query.Has(k) || !mockValueMatches(v, query.Get(k)) {
+			return nil, false
+		}
+	}
+	for k, v := range c.Request.Headers {
+		if r.Header.Get(k) == "" || !mockValueMatches(v, r.Header.Get(k)) {
+			return nil, false
+		}
+	}
+	if len(c.Request.Body) > 0 && !mockBodyMatches(c.Request.Body, body) {
+		return nil, false
+	}
+	return params, true
+}
+
+// mockValueMatches 比较期望值和实际值，"*" 或包含 {{...}} 的期望值匹配任意值
+func mockValueMatches(expected, actual any) bool {
+	if s, ok := expected.(string); ok && (s == "*" || strings.Contains(s, "{{")) {
+		return true
+	}
+	return fmt.Sprint(expected) == fmt.Sprint(actual)
+}
+
+// mockRequestBody 解析请求体用于匹配：表单和 multipart 的字段转换为对象（上传的文件取文件名），其他按 JSON 解析
+func mockRequestBody(r *http.Request) any {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+			return nil
+		}
+		body := make(map[string]any, len(r.PostForm))
+		for key, values := range r.PostForm {
+			body[key] = mockFormValue(values)
+		}
+		if r.MultipartForm != nil {
+			for key, files := range r.MultipartForm.File {
+				names := make([]string, len(files))
+				for i, f := range files {
+					names[i] = f.Filename
+				}
+				body[key] = mockFormValue(names)
+			}
+		}
+		return body
+	}
+
+	var body any
+	if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+		body, _ = decodeJSON(data)
+	}
+	return body
+}
+
+// mockFormValue 单个值返回字符串，多个同名值返回数组
+func mockFormValue(values []string) any {
+	if len(values) == 1 {
+		return values[0]
+	}
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
+
+// mockBodyMatches 判断请求体是否包含期望的字段
+func mockBodyMatches(expected map[string]any, actual any) bool {
+	obj, ok := actual.(map[string]any)
+	if !ok {
+		return false
+	}
+	for k, want := range expected {
+		got, ok := obj[k]
+		if !ok {
+			return false
+		}
+		if nested, ok := want.(map[string]any); ok {
+			if !mockBodyMatches(nested, got) {
+				return false
+			}
+			continue
+		}
+		if !mockValueMatches(want, got) {
+			return false
+		}
+	}
+	return true
+}
+
+// countFields 统计请求体匹配条件的字段数
+func countFields(body map[string]any) int {
+	n := 0
+	for _, v := range body {
+		n++
+		if nested, ok := v.(map[string]any); ok {
+			n += countFields(nested)
+		}
+	}
+	return n
+}
+
+// mockSimplePath 可以直接写入响应体的路径：a.b.c
+var mockSimplePath = regexp.MustCompile(`^[A-Za-z_][\w-]*(\.[A-Za-z_][\w-]*)*$`)
+
+// mockFromTestCase 由测试用例推导模拟接口
+func mockFromTestCase(tc TestCase) Mock {
+	mock := Mock{
+		Name: tc.Name,
+		Request: MockRequest{
+			Method: tc.Request.Method,
+			Path:   tc.Request.Path,
+			Query:  tc.Request.Query,
+			Body:   tc.Request.Body,
+		},
+		Response: MockResponse{Status: tc.Expect.StatusCode},
+	}
+
+	body := make(map[string]any)
+	for key, value := range tc.Expect.ResponseBody {
+		setMockPath(body, key, value)
+	}
+	for _, a := range tc.Expect.Assertions {
+		if a.Operator == "equals" {
+			setMockPath(body, strings.TrimPrefix(a.Path, "$."), a.Value)
+		}
+	}
+	for _, path := range tc.Save {
+		path = strings.TrimPrefix(path, "$.")
+		if _, found, _ := resolvePath(path, body); !found {
+			setMockPath(body, path, "{{uuid}}")
+		}
+	}
+	if len(body) > 0 {
+		mock.Response.Body = body
+	}
+	return mock
+}
+
+// setMockPath 按点号路径设置值，不支持的路径忽略
+func setMockPath(body map[string]any, path string, value any) {
+	if !mockSimplePath.MatchString(path) {
+		return
+	}
+	keys := strings.Split(path, ".")
+	current := body
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := current[key].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			current[key] = next
+		}
+		current = next
+	}
+	current[keys[len(keys)-1]] = value
+}
@@ -0,0 +1,182 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMockServer(t *testing.T) {
+	suite, err := ParseSuite([]byte(`
+suite:
+  name: "Mocked Orders"
+  base_url: ""
+variables:
+  region: "eu"
+mocks:
+  - name: "Get order"
+    request: { method: "GET", path: "/orders/{id}" }
+    response:
+      headers: { X-Region: "{{region}}" }
+      body: { id: "{{path.id}}", region: "{{region}}", note: "order {{path.id}} in {{region}}" }
+  - name: "Search orders"
+    request: { method: "GET", path: "/orders", query: { status: "paid" } }
+    response:
+      body: { items: [{ id: "A1" }], status: "{{query.status}}" }
+  - name: "Text ping"
+    request: { path: "/ping" }
+    response: { body: "pong" }
+scenarios:
+  - name: "Orders"
+    testcases:
+      - name: "Create order"
+        request: { method: "POST", path: "/orders", body: { sku: "X-1", qty: 2 } }
+        expect:
+          status_code: 201
+          response_body: { status: "created" }
+          assertions:
+            - { path: "data.qty", operator: "equals", value: 2 }
+        save: { order_id: "data.id" }
+      - name: "Get saved order"
+        request: { method: "GET", path: "/orders/{{order_id}}" }
+        expect:
+          status_code: 200
+          assertions:
+            - { path: "region", operator: "equals", value: "eu" }
+`))
+	if err != nil {
+		t.Fatalf("ParseSuite failed: %v", err)
+	}
+
+	mock := NewMockServer(suite)
+	var log strings.Builder
+	mock.SetOutput(&log)
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	get := func(path string) (*http.Response, map[string]any) {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		var body map[string]any
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp, body
+	}
+
+	resp, body := get("/orders/42")
+	if resp.StatusCode != 200 || body["id"] != "42" || body["note"] != "order 42 in eu" {
+		t.Errorf("Unexpected templated response: %d %v", resp.StatusCode, body)
+	}
+	if resp.Header.Get("X-Region") != "eu" {
+		t.Errorf("Expected templated header, got %q", resp.Header.Get("X-Region"))
+	}
+
+	if resp, body := get("/orders?status=paid"); resp.StatusCode != 200 || body["status"] != "paid" {
+		t.Errorf("Expected query match, got %d %v", resp.StatusCode, body)
+	}
+	if resp, _ := get("/orders?status=open"); resp.StatusCode != 404 {
+		t.Errorf("Expected 404 for unmatched query, got %d", resp.StatusCode)
+	}
+
+	ping, err := http.Post(server.URL+"/ping", "text/plain", nil)
+	if err != nil {
+		t.Fatalf("POST /ping failed: %v", err)
+	}
+	text, _ := io.ReadAll(ping.Body)
+	ping.Body.Close()
+	if string(text) != "pong" {
+		t.Errorf("Expected text body for any method, got %q", text)
+	}
+
+	created, err := http.Post(server.URL+"/orders", "application/json", strings.NewReader(`{"sku": "X-1", "qty": 2}`))
+	if err != nil {
+		t.Fatalf("POST /orders failed: %v", err)
+	}
+	var order map[string]any
+	json.NewDecoder(created.Body).Decode(&order)
+	created.Body.Close()
+	data, _ := order["data"].(map[string]any)
+	if created.StatusCode != 201 || order["status"] != "created" || data["qty"] != float64(2) {
+		t.Errorf("Expected response derived from test case, got %d %v", created.StatusCode, order)
+	}
+	if id, _ := data["id"].(string); len(id) != 36 {
+		t.Errorf("Expected saved field filled with uuid, got %v", data["id"])
+	}
+
+	if !strings.Contains(log.String(), "🎭 GET /orders/42 → 200 (Get order)") {
+		t.Errorf("Expected request log, got:\n%s", log.String())
+	}
+
+	// 套件可以直接在自己的模拟服务上运行
+	suite.Suite.BaseURL = server.URL
+	var replay bytes.Buffer
+	if err := WriteSuite(&replay, suite); err != nil {
+		t.Fatalf("WriteSuite failed: %v", err)
+	}
+	configPath := filepath.Join(t.TempDir(), "orders.yaml")
+	os.WriteFile(configPath, replay.Bytes(), 0644)
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("NewTestRunner failed: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, result := range runner.GetResults() {
+		if !result.Passed {
+			t.Errorf("Test %q failed against its own mock: %s", result.Name, result.Error)
+		}
+	}
+}
+
+func TestMockServerConcurrentRequests(t *testing.T) {
+	suite, err := ParseSuite([]byte(`
+suite:
+  name: "Concurrent Mock"
+  base_url: ""
+mocks:
+  - name: "Create item"
+    request: { method: "POST", path: "/items", body: { meta: { kind: "book" } } }
+    response:
+      status: 201
+      body: { id: "{{uuid}}", meta: { kind: "{{body.meta.kind}}", codes: { 1: "one" } } }
+`))
+	if err != nil {
+		t.Fatalf("ParseSuite failed: %v", err)
+	}
+	mock := NewMockServer(suite)
+	mock.SetOutput(io.Discard)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"meta": {"kind": "book"}}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			mock.ServeHTTP(rec, req)
+
+			var body map[string]any
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			meta, _ := body["meta"].(map[string]any)
+			codes, _ := meta["codes"].(map[string]any)
+			if rec.Code != 201 || meta["kind"] != "book" || codes["1"] != "one" {
+				t.Errorf("Unexpected concurrent response: %d %s", rec.Code, rec.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+}
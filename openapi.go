@@ -0,0 +1,521 @@
+package apitest
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPI 校验模式
+const (
+	OpenAPIModeStrict = "strict" // 违反规范记为失败（默认）
+	OpenAPIModeWarn   = "warn"   // 违反规范只记录警告
+)
+
+// openAPIMethods OpenAPI 路径项中的 HTTP 方法
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// OpenAPISpec 解析后的 OpenAPI 3 规范
+type OpenAPISpec struct {
+	Title      string
+	Version    string
+	Servers    []string // servers 中声明的地址
+	Operations []*OpenAPIOperation
+
+	doc      map[string]any // 原始文档，用于解析 $ref
+	prefixes []string       // servers 中声明的路径前缀
+}
+
+// OpenAPIOperation 规范中的一个操作（方法 + 路径模板）
+type OpenAPIOperation struct {
+	Method      string // 大写，例如 GET
+	Path        string // 路径模板，例如 /users/{id}
+	OperationID string
+	Summary     string
+	Tags        []string
+
+	spec       *OpenAPISpec
+	raw        map[string]any
+	parameters []map[string]any // 合并了路径级和操作级的参数，$ref 已解析
+	pattern    *regexp.Regexp
+	params     []string // 路径参数名，顺序与 pattern 的分组一致
+}
+
+// LoadOpenAPI 从 JSON 或 YAML 文件加载 OpenAPI 3 规范
+func LoadOpenAPI(path string) (*OpenAPISpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read openapi spec: %w", err)
+	}
+	spec, err := ParseOpenAPI(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// ParseOpenAPI 解析 JSON 或 YAML 格式的 OpenAPI 3 规范
+func ParseOpenAPI(data []byte) (*OpenAPISpec, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse openapi spec: %w", err)
+	}
+	doc, ok := normalizeYAML(raw).(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("openapi spec must be an object")
+	}
+
+	version := fmt.Sprint(doc["openapi"])
+	if !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version '%v', only 3.x is supported", doc["openapi"])
+	}
+
+	spec := &OpenAPISpec{doc: doc}
+	if info, ok := doc["info"].(map[string]any); ok {
+		spec.Title, _ = info["title"].(string)
+		spec.Version = fmt.Sprint(info["version"])
+	}
+
+	if servers, ok := doc["servers"].([]any); ok {
+		for _, s := range servers {
+			server, _ := s.(map[string]any)
+			spec.Servers = append(spec.Servers, fmt.Sprint(server["url"]))
+			if u, err := url.Parse(fmt.Sprint(server["url"])); err == nil {
+				if prefix := strings.TrimSuffix(u.Path, "/"); prefix != "" {
+					spec.prefixes = append(spec.prefixes, prefix)
+				}
+			}
+		}
+	}
+
+	paths, _ := doc["paths"].(map[string]any)
+	templates := make([]string, 0, len(paths))
+	for p := range paths {
+		templates = append(templates, p)
+	}
+	sort.Strings(templates)
+
+	for _, template := range templates {
+		item := spec.resolve(paths[template])
+		pathParams := spec.resolveParameters(item["parameters"])
+
+		for _, method := range openAPIMethods {
+			raw := spec.resolve(item[method])
+			if raw == nil {
+				continue
+			}
+
+			op := &OpenAPIOperation{
+				Method: strings.ToUpper(method),
+				Path:   template,
+				spec:   spec,
+				raw:    raw,
+			}
+			op.OperationID, _ = raw["operationId"].(string)
+			op.Summary, _ = raw["summary"].(string)
+			if tags, ok := raw["tags"].([]any); ok {
+				for _, tag := range tags {
+					op.Tags = append(op.Tags, fmt.Sprint(tag))
+				}
+			}
+			op.parameters = mergeParameters(pathParams, spec.resolveParameters(raw["parameters"]))
+			op.pattern, op.params = compilePathTemplate(template)
+			spec.Operations = append(spec.Operations, op)
+		}
+	}
+
+	// 参数越少的模板越具体，优先匹配：/users/me 先于 /users/{id}
+	sort.SliceStable(spec.Operations, func(i, j int) bool {
+		return len(spec.Operations[i].params) < len(spec.Operations[j].params)
+	})
+	return spec, nil
+}
+
+// compilePathTemplate 将路径模板编译为正则，返回路径参数名
+func compilePathTemplate(template string) (*regexp.Regexp, []string) {
+	var params []string
+	var b strings.Builder
+	b.WriteString("^")
+	for {
+		start := strings.Index(template, "{")
+		end := strings.Index(template, "}")
+		if start < 0 || end < start {
+			b.WriteString(regexp.QuoteMeta(template))
+			break
+		}
+		b.WriteString(regexp.QuoteMeta(template[:start]))
+		b.WriteString("([^/]+)")
+		params = append(params, template[start+1:end])
+		template = template[end+1:]
+	}
+	b.WriteString("/?$")
+	return regexp.MustCompile(b.String()), params
+}
+
+// resolve 解析 $ref 并返回对象，非对象返回 nil
+func (s *OpenAPISpec) resolve(node any) map[string]any {
+	for i := 0; i < maxSchemaDepth; i++ {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return m
+		}
+		target, err := resolveSchemaRef(s.doc, ref)
+		if err != nil {
+			return nil
+		}
+		node = target
+	}
+	return nil
+}
+
+// resolveParameters 解析参数列表中的 $ref
+func (s *OpenAPISpec) resolveParameters(node any) []map[string]any {
+	list, _ := node.([]any)
+	params := make([]map[string]any, 0, len(list))
+	for _, p := range list {
+		if param := s.resolve(p); param != nil {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// mergeParameters 合并路径级和操作级参数，同名同位置时操作级优先
+func mergeParameters(pathParams, opParams []map[string]any) []map[string]any {
+	key := func(p map[string]any) string { return fmt.Sprint(p["in"], ":", p["name"]) }
+	seen := make(map[string]bool)
+	var merged []map[string]any
+	for _, p := range opParams {
+		seen[key(p)] = true
+		merged = append(merged, p)
+	}
+	for _, p := range pathParams {
+		if !seen[key(p)] {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+// FindOperation 按方法和请求路径匹配操作，返回操作和路径参数
+// 请求路径可以包含 servers 中声明的前缀
+func (s *OpenAPISpec) FindOperation(method, path string) (*OpenAPIOperation, map[string]string) {
+	candidates := []string{path}
+	for _, prefix := range s.prefixes {
+		if strings.HasPrefix(path, prefix) {
+			candidates = append(candidates, strings.TrimPrefix(path, prefix))
+		}
+	}
+
+	method = strings.ToUpper(method)
+	for _, candidate := range candidates {
+		for _, op := range s.Operations {
+			if op.Method != method {
+				continue
+			}
+			match := op.pattern.FindStringSubmatch(candidate)
+			if match == nil {
+				continue
+			}
+			values := make(map[string]string, len(op.params))
+			for i, name := range op.params {
+				values[name], _ = url.PathUnescape(match[i+1])
+			}
+			return op, values
+		}
+	}
+	return nil, nil
+}
+
+// ValidateRequest 校验请求的参数和请求体，返回违反项描述
+func (op *OpenAPIOperation) ValidateRequest(req *RequestData, pathParams map[string]string) []string {
+	var violations []string
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return []string{fmt.Sprintf("request url: %v", err)}
+	}
+	query := u.Query()
+	headers := http.Header(req.Headers)
+
+	for _, param := range op.parameters {
+		name := fmt.Sprint(param["name"])
+		in := fmt.Sprint(param["in"])
+		required, _ := param["required"].(bool)
+
+		var value string
+		var present bool
+		switch in {
+		case "path":
+			value, present = pathParams[name]
+			required = true
+		case "query":
+			present = query.Has(name)
+			value = query.Get(name)
+		case "header":
+			present = headers.Get(name) != ""
+			value = headers.Get(name)
+		case "cookie":
+			if c, err := (&http.Request{Header: headers}).Cookie(name); err == nil {
+				present, value = true, c.Value
+			}
+		default:
+			continue
+		}
+
+		location := fmt.Sprintf("request %s parameter '%s'", in, name)
+		if !present {
+			if required {
+				violations = append(violations, location+": missing required parameter")
+			}
+			continue
+		}
+		if schema, ok := param["schema"]; ok {
+			instance := coerceParameter(op.spec.resolve(schema), value)
+			violations = append(violations, op.spec.validate(location, schema, instance)...)
+		}
+	}
+
+	body := op.spec.resolve(op.raw["requestBody"])
+	if body == nil {
+		return violations
+	}
+	required, _ := body["required"].(bool)
+	if req.Body == "" {
+		if required {
+			violations = append(violations, "request body: missing required body")
+		}
+		return violations
+	}
+
+	content, _ := body["content"].(map[string]any)
+	mediaType, media := matchContent(content, headers.Get("Content-Type"))
+	switch {
+	case media == nil && len(content) > 0:
+		violations = append(violations, fmt.Sprintf("request body: content type '%s' is not declared", headers.Get("Content-Type")))
+	case media != nil && isJSONMediaType(mediaType):
+		if schema, ok := media["schema"]; ok {
+			instance, err := decodeJSON([]byte(req.Body))
+			if err != nil {
+				violations = append(violations, fmt.Sprintf("request body: invalid JSON: %v", err))
+			} else {
+				violations = append(violations, op.spec.validate("request body", schema, instance)...)
+			}
+		}
+	}
+	return violations
+}
+
+// ValidateResponse 校验响应的状态码、响应头和响应体，返回违反项描述
+// body 为解析后的 JSON 响应体
+func (op *OpenAPIOperation) ValidateResponse(statusCode int, headers http.Header, body any, hasBody bool) []string {
+	response := op.Response(statusCode)
+	if response == nil {
+		return []string{fmt.Sprintf("response status %d is not declared", statusCode)}
+	}
+
+	var violations []string
+	if declared, ok := response["headers"].(map[string]any); ok {
+		names := make([]string, 0, len(declared))
+		for name := range declared {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			header := op.spec.resolve(declared[name])
+			if header == nil || strings.EqualFold(name, "Content-Type") {
+				continue
+			}
+			location := fmt.Sprintf("response header '%s'", name)
+			value := headers.Get(name)
+			if value == "" {
+				if required, _ := header["required"].(bool); required {
+					violations = append(violations, location+": missing required header")
+				}
+				continue
+			}
+			if schema, ok := header["schema"]; ok {
+				instance := coerceParameter(op.spec.resolve(schema), value)
+				violations = append(violations, op.spec.validate(location, schema, instance)...)
+			}
+		}
+	}
+
+	content, _ := response["content"].(map[string]any)
+	if len(content) == 0 || !hasBody {
+		return violations
+	}
+	mediaType, media := matchContent(content, headers.Get("Content-Type"))
+	if media == nil {
+		return append(violations, fmt.Sprintf("response body: content type '%s' is not declared", headers.Get("Content-Type")))
+	}
+	if schema, ok := media["schema"]; ok && isJSONMediaType(mediaType) {
+		violations = append(violations, op.spec.validate("response body", schema, body)...)
+	}
+	return violations
+}
+
+// Response 返回状态码对应的响应定义，依次匹配精确状态码、2XX 形式和 default
+func (op *OpenAPIOperation) Response(statusCode int) map[string]any {
+	key := op.ResponseKey(statusCode)
+	if key == "" {
+		return nil
+	}
+	responses, _ := op.raw["responses"].(map[string]any)
+	return op.spec.resolve(responses[key])
+}
+
+// ResponseKey 返回状态码匹配到的响应码声明（例如 "200"、"2XX"、"default"），未声明时返回空字符串
+func (op *OpenAPIOperation) ResponseKey(statusCode int) string {
+	responses, _ := op.raw["responses"].(map[string]any)
+	code := strconv.Itoa(statusCode)
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if _, ok := responses[key]; ok {
+			return key
+		}
+	}
+	return ""
+}
+
+// ResponseCodes 返回操作声明的响应码（包括 2XX 和 default），已排序
+func (op *OpenAPIOperation) ResponseCodes() []string {
+	responses, _ := op.raw["responses"].(map[string]any)
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// String 返回 "GET /users/{id}" 形式的描述
+func (op *OpenAPIOperation) String() string {
+	return op.Method + " " + op.Path
+}
+
+// validate 使用规范文档作为 $ref 根节点校验 instance
+func (s *OpenAPISpec) validate(location string, schema, instance any) []string {
+	v := &schemaValidator{root: s.doc, patterns: make(map[string]*regexp.Regexp)}
+	v.validate(schema, instance, "")
+
+	violations := make([]string, 0, len(v.violations))
+	for _, violation := range v.violations {
+		if violation.Pointer == "" {
+			violations = append(violations, location+": "+violation.Message)
+		} else {
+			violations = append(violations, location+" "+violation.String())
+		}
+	}
+	return violations
+}
+
+// coerceParameter 按 schema 类型将字符串参数转换为对应的 JSON 值
+func coerceParameter(schema map[string]any, value string) any {
+	typ, _ := schema["type"].(string)
+	switch typ {
+	case "integer":
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		var arr []any
+		for _, part := range strings.Split(value, ",") {
+			arr = append(arr, coerceParameter(items, part))
+		}
+		return arr
+	}
+	return value
+}
+
+// matchContent 按 Content-Type 匹配 content 中的媒体类型，支持 application/* 和 */* 通配
+// 通配匹配时返回实际的媒体类型，调用方据此判断是否按 JSON 校验
+// contentType 为空时优先返回 JSON 媒体类型
+func matchContent(content map[string]any, contentType string) (string, map[string]any) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	if mediaType == "" {
+		for key, media := range content {
+			if isJSONMediaType(key) {
+				m, _ := media.(map[string]any)
+				return key, m
+			}
+		}
+		// 只声明了 */* 时视为已声明，但不知道实际类型，返回空的媒体类型
+		if media, ok := content["*/*"]; ok {
+			m, _ := media.(map[string]any)
+			return "", m
+		}
+		return "", nil
+	}
+
+	if media, ok := content[mediaType]; ok {
+		m, _ := media.(map[string]any)
+		return mediaType, m
+	}
+	for _, key := range []string{strings.SplitN(mediaType, "/", 2)[0] + "/*", "*/*"} {
+		if media, ok := content[key]; ok {
+			m, _ := media.(map[string]any)
+			return mediaType, m
+		}
+	}
+	return "", nil
+}
+
+// isJSONMediaType 判断是否为 JSON 媒体类型（application/json、application/problem+json 等）
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// validateOpenAPI 使用套件的 OpenAPI 规范校验请求和响应
+func (r *TestRunner) validateOpenAPI(req *RequestData, resp *http.Response, respData any, hasBody bool) []string {
+	op, params, err := r.matchOperation(r.openapi, req.Method, req.URL)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	violations := op.ValidateRequest(req, params)
+	return append(violations, op.ValidateResponse(resp.StatusCode, resp.Header, respData, hasBody)...)
+}
+
+// matchOperation 将请求匹配到规范中的操作，请求路径会先去掉 base_url 中的路径前缀
+func (r *TestRunner) matchOperation(spec *OpenAPISpec, method, rawURL string) (*OpenAPIOperation, map[string]string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request url: %v", err)
+	}
+
+	path := u.Path
+	if base, err := url.Parse(r.suite.Suite.BaseURL); err == nil && base.Path != "" {
+		path = "/" + strings.TrimPrefix(strings.TrimPrefix(path, strings.TrimSuffix(base.Path, "/")), "/")
+	}
+
+	op, params := spec.FindOperation(method, path)
+	if op == nil {
+		op, params = spec.FindOperation(method, u.Path)
+	}
+	if op == nil {
+		return nil, nil, fmt.Errorf("no operation matches %s %s", method, u.Path)
+	}
+	return op, params, nil
+}
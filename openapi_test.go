@@ -0,0 +1,198 @@
+package apitest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testOpenAPISpec = `
+openapi: 3.0.3
+info: { title: Users, version: "1.0" }
+servers:
+  - url: http://localhost/api
+paths:
+  /users/me:
+    get:
+      operationId: getMe
+      responses:
+        "200": { $ref: "#/components/responses/User" }
+  /users/{id}:
+    parameters:
+      - { name: id, in: path, required: true, schema: { type: integer } }
+    get:
+      operationId: getUser
+      parameters:
+        - { name: fields, in: query, schema: { type: string, enum: [basic, full] } }
+      responses:
+        "200": { $ref: "#/components/responses/User" }
+        4XX:
+          description: error
+          content:
+            application/json:
+              schema: { type: object, required: [message] }
+  /users:
+    post:
+      operationId: createUser
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: { $ref: "#/components/schemas/User" }
+      responses:
+        "201":
+          description: created
+          headers:
+            Location: { required: true, schema: { type: string } }
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, name]
+      properties:
+        id: { type: integer }
+        name: { type: string }
+        email: { type: string, nullable: true }
+  responses:
+    User:
+      description: user
+      content:
+        application/json:
+          schema: { $ref: "#/components/schemas/User" }
+`
+
+func TestOpenAPISpec(t *testing.T) {
+	spec, err := ParseOpenAPI([]byte(testOpenAPISpec))
+	if err != nil {
+		t.Fatalf("ParseOpenAPI failed: %v", err)
+	}
+	if len(spec.Operations) != 3 {
+		t.Fatalf("Expected 3 operations, got %d", len(spec.Operations))
+	}
+
+	tests := []struct {
+		method, path, want string
+	}{
+		{"GET", "/users/me", "getMe"},
+		{"GET", "/users/42", "getUser"},
+		{"get", "/api/users/42", "getUser"},
+		{"POST", "/users", "createUser"},
+		{"DELETE", "/users/42", ""},
+	}
+	for _, tt := range tests {
+		op, _ := spec.FindOperation(tt.method, tt.path)
+		got := ""
+		if op != nil {
+			got = op.OperationID
+		}
+		if got != tt.want {
+			t.Errorf("FindOperation(%s %s) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+
+	op, params := spec.FindOperation("GET", "/users/abc")
+	violations := op.ValidateRequest(&RequestData{Method: "GET", URL: "http://localhost/users/abc?fields=all"}, params)
+	if len(violations) != 2 {
+		t.Errorf("Expected path and query violations, got %v", violations)
+	}
+
+	body := map[string]any{"id": int64(1), "name": "John", "email": nil}
+	if v := op.ValidateResponse(200, http.Header{}, body, true); len(v) != 0 {
+		t.Errorf("Expected nullable email to be accepted, got %v", v)
+	}
+	if v := op.ValidateResponse(404, http.Header{}, map[string]any{}, true); len(v) != 1 || !strings.Contains(v[0], "message") {
+		t.Errorf("Expected 4XX body violation, got %v", v)
+	}
+	if v := op.ValidateResponse(500, http.Header{}, nil, false); len(v) != 1 || !strings.Contains(v[0], "500 is not declared") {
+		t.Errorf("Expected undeclared status violation, got %v", v)
+	}
+
+	create, _ := spec.FindOperation("POST", "/users")
+	if v := create.ValidateRequest(&RequestData{Method: "POST", URL: "http://localhost/users"}, nil); len(v) != 1 {
+		t.Errorf("Expected missing body violation, got %v", v)
+	}
+	if v := create.ValidateResponse(201, http.Header{}, nil, false); len(v) != 1 || !strings.Contains(v[0], "Location") {
+		t.Errorf("Expected missing header violation, got %v", v)
+	}
+}
+
+func TestMatchContent(t *testing.T) {
+	jsonMedia := map[string]any{"schema": map[string]any{"type": "object"}}
+	anyMedia := map[string]any{"schema": map[string]any{"type": "string"}}
+	tests := []struct {
+		content     map[string]any
+		contentType string
+		wantType    string
+		wantMedia   map[string]any
+	}{
+		{map[string]any{"application/json": jsonMedia}, "", "application/json", jsonMedia},
+		{map[string]any{"*/*": anyMedia}, "", "", anyMedia},
+		{map[string]any{"*/*": anyMedia}, "application/json; charset=utf-8", "application/json", anyMedia},
+		{map[string]any{"*/*": anyMedia}, "text/plain", "text/plain", anyMedia},
+		{map[string]any{"application/json": jsonMedia}, "text/plain", "", nil},
+	}
+	for _, tt := range tests {
+		gotType, gotMedia := matchContent(tt.content, tt.contentType)
+		if gotType != tt.wantType || !reflect.DeepEqual(gotMedia, tt.wantMedia) {
+			t.Errorf("matchContent(%v, %q) = %q, %v; want %q, %v", tt.content, tt.contentType, gotType, gotMedia, tt.wantType, tt.wantMedia)
+		}
+	}
+	if isJSONMediaType("*/*") {
+		t.Error("isJSONMediaType should not accept the */* wildcard")
+	}
+}
+
+func TestTestRunnerOpenAPIValidation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id": "1", "name": "John"}`)
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "openapi.yaml"), []byte(testOpenAPISpec), 0644)
+
+	for _, mode := range []string{OpenAPIModeStrict, OpenAPIModeWarn} {
+		configPath := filepath.Join(tempDir, mode+".yaml")
+		os.WriteFile(configPath, []byte(`
+suite:
+  name: "OpenAPI Suite"
+  base_url: "`+server.URL+`"
+  openapi: "openapi.yaml"
+  openapi_mode: "`+mode+`"
+scenarios:
+  - name: "Contract"
+    testcases:
+      - name: "Get User"
+        request: { method: "GET", path: "/users/1" }
+        expect: { status_code: 200 }
+`), 0644)
+
+		runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+		if err != nil {
+			t.Fatalf("Failed to create TestRunner: %v", err)
+		}
+		runner.SetOutput(io.Discard)
+		if err := runner.Run(context.Background()); err != nil {
+			t.Fatalf("TestRunner.Run failed: %v", err)
+		}
+
+		result := runner.GetResults()[0]
+		switch mode {
+		case OpenAPIModeStrict:
+			if result.Passed || !strings.Contains(result.Error, "response body /id: expected integer, got string") {
+				t.Errorf("Expected strict mode to fail on contract violation, got %+v", result)
+			}
+		case OpenAPIModeWarn:
+			if !result.Passed || len(result.Warnings) != 1 {
+				t.Errorf("Expected warn mode to pass with one warning, got %+v", result)
+			}
+		}
+	}
+}
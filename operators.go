@@ -0,0 +1,426 @@
+package apitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// AssertionContext 断言函数的输入
+type AssertionContext struct {
+	Path     string // 断言路径
+	Actual   any    // 路径解析出的实际值，路径不存在时为 nil
+	Found    bool   // 路径是否存在
+	Expected any    // 期望值，字符串中的变量已替换
+}
+
+// AssertionFunc 断言函数，不满足时返回描述原因的错误（框架会加上 "assertion failed: " 前缀）
+type AssertionFunc func(c AssertionContext) error
+
+// OperatorInfo 已注册的断言操作符
+type OperatorInfo struct {
+	Name        string
+	Description string
+	Builtin     bool
+}
+
+// operator 注册表中的操作符
+type operator struct {
+	OperatorInfo
+	fn AssertionFunc
+}
+
+var (
+	operatorsMu sync.RWMutex
+	operators   = make(map[string]operator)
+)
+
+// RegisterOperator 注册断言操作符，可在 YAML 的 assertions 中通过 operator 使用
+// 同名操作符（包括内置操作符）会被替换；应在运行测试之前注册，例如在 init 或 TestMain 中
+func RegisterOperator(name, description string, fn AssertionFunc) {
+	registerOperator(name, description, fn, false)
+}
+
+// registerOperator 注册操作符
+func registerOperator(name, description string, fn AssertionFunc, builtin bool) {
+	if name == "" || fn == nil {
+		panic("apitest: RegisterOperator requires a name and a function")
+	}
+	operatorsMu.Lock()
+	defer operatorsMu.Unlock()
+	operators[name] = operator{OperatorInfo: OperatorInfo{Name: name, Description: description, Builtin: builtin}, fn: fn}
+}
+
+// lookupOperator 查找操作符
+func lookupOperator(name string) (AssertionFunc, bool) {
+	operatorsMu.RLock()
+	defer operatorsMu.RUnlock()
+	op, ok := operators[name]
+	return op.fn, ok
+}
+
+// Operators 返回所有已注册的操作符，按名称排序
+func Operators() []OperatorInfo {
+	operatorsMu.RLock()
+	defer operatorsMu.RUnlock()
+	infos := make([]OperatorInfo, 0, len(operators))
+	for _, op := range operators {
+		infos = append(infos, op.OperatorInfo)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+func init() {
+	registerOperator("equals", "值相等（数字按数值比较）", assertEquals, true)
+	registerOperator("notEquals", "值不相等", assertNotEquals, true)
+	registerOperator("contains", "数组包含元素，或字符串包含子串", assertContains, true)
+	registerOperator("length", "数组、对象或字符串的长度", assertLength, true)
+	registerOperator("startsWith", "字符串以 value 开头", assertStartsWith, true)
+	registerOperator("notEmpty", "值不为 null 或空字符串", assertNotEmpty, true)
+	registerOperator("isArray", "值为数组", assertIsArray, true)
+	registerOperator("greaterThan", "数字大于 value", compareNumbers("greater than", func(a, b float64) bool { return a > b }), true)
+	registerOperator("greaterThanOrEqual", "数字大于等于 value", compareNumbers(">=", func(a, b float64) bool { return a >= b }), true)
+	registerOperator("lessThan", "数字小于 value", compareNumbers("less than", func(a, b float64) bool { return a < b }), true)
+	registerOperator("lessThanOrEqual", "数字小于等于 value", compareNumbers("<=", func(a, b float64) bool { return a <= b }), true)
+	registerOperator("between", "数字在 [min, max] 之间（包含边界），value 为 [min, max]", assertBetween, true)
+	registerOperator("notContains", "数组不包含元素，或字符串不包含子串", assertNotContains, true)
+	registerOperator("endsWith", "字符串以 value 结尾", assertEndsWith, true)
+	registerOperator("matches", "字符串匹配正则表达式 value", assertMatches, true)
+	registerOperator("in", "值等于 value 数组中的某一项", assertIn, true)
+	registerOperator("notIn", "值不等于 value 数组中的任何一项", assertNotIn, true)
+	registerOperator("minLength", "数组、对象或字符串的长度不小于 value", compareLength("at least", func(n, want int64) bool { return n >= want }), true)
+	registerOperator("maxLength", "数组、对象或字符串的长度不大于 value", compareLength("at most", func(n, want int64) bool { return n <= want }), true)
+	registerOperator("isNull", "字段存在且值为 null", assertIsNull, true)
+	registerOperator("isEmpty", "值为 null、空字符串、空数组或空对象", assertIsEmpty, true)
+	registerOperator("exists", "字段存在（值可以为 null）", assertExists, true)
+	registerOperator("notExists", "字段不存在", assertNotExists, true)
+	registerOperator("type", "JSON 类型：string、number、integer、boolean、object、array 或 null", assertType, true)
+	registerOperator("isUUID", "字符串为 UUID", assertFormat("uuid", "UUID"), true)
+	registerOperator("isISO8601", "字符串为 ISO 8601 日期（2006-01-02）或日期时间（RFC 3339）", assertISO8601, true)
+	registerOperator("deepEquals", "对象或数组完全相等（数字按数值比较，对象键无序）", assertDeepEquals, true)
+	registerOperator("each", "数组的每个元素都满足子断言，value 为 {path, operator, value}，path 相对于元素", assertEach, true)
+}
+
+// assertEquals equals 操作符
+func assertEquals(c AssertionContext) error {
+	if !valuesEqual(c.Actual, c.Expected) {
+		return fmt.Errorf("%s should equal %v (type: %T), got %v (type: %T)",
+			c.Path, c.Expected, c.Expected, c.Actual, c.Actual)
+	}
+	return nil
+}
+
+// assertNotEquals notEquals 操作符
+func assertNotEquals(c AssertionContext) error {
+	if valuesEqual(c.Actual, c.Expected) {
+		return fmt.Errorf("%s should not equal %v", c.Path, c.Expected)
+	}
+	return nil
+}
+
+// assertContains contains 操作符：数组（包括通配符、过滤器的结果）判断是否包含元素，其他值判断子串
+func assertContains(c AssertionContext) error {
+	if arr, ok := c.Actual.([]any); ok {
+		for _, item := range arr {
+			if valuesEqual(item, c.Expected) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s should contain %v, got %v", c.Path, c.Expected, arr)
+	}
+	str := fmt.Sprint(c.Actual)
+	substr := fmt.Sprint(c.Expected)
+	if !strings.Contains(str, substr) {
+		return fmt.Errorf("%s should contain %s, got %s", c.Path, substr, str)
+	}
+	return nil
+}
+
+// assertLength length 操作符
+func assertLength(c AssertionContext) error {
+	length, ok := valueLength(c.Actual)
+	if !ok {
+		return fmt.Errorf("%s should be array, object or string, got %T", c.Path, c.Actual)
+	}
+	expectedLen, ok := toInt64(c.Expected)
+	if !ok {
+		return fmt.Errorf("expected length should be integer")
+	}
+	if int64(length) != expectedLen {
+		return fmt.Errorf("%s should have length %d, got %d", c.Path, expectedLen, length)
+	}
+	return nil
+}
+
+// assertStartsWith startsWith 操作符
+func assertStartsWith(c AssertionContext) error {
+	str := fmt.Sprint(c.Actual)
+	prefix := fmt.Sprint(c.Expected)
+	if !strings.HasPrefix(str, prefix) {
+		return fmt.Errorf("%s should start with %s, got %s", c.Path, prefix, str)
+	}
+	return nil
+}
+
+// assertNotEmpty notEmpty 操作符
+func assertNotEmpty(c AssertionContext) error {
+	if c.Actual == nil || c.Actual == "" {
+		return fmt.Errorf("%s should not be empty", c.Path)
+	}
+	return nil
+}
+
+// assertIsArray isArray 操作符
+func assertIsArray(c AssertionContext) error {
+	if _, ok := c.Actual.([]any); !ok {
+		return fmt.Errorf("%s should be array", c.Path)
+	}
+	return nil
+}
+
+// compareNumbers 创建数字比较操作符，relation 用于错误信息
+func compareNumbers(relation string, ok func(actual, expected float64) bool) AssertionFunc {
+	return func(c AssertionContext) error {
+		numVal, isNum := toFloat64(c.Actual)
+		if !isNum {
+			return fmt.Errorf("%s should be number", c.Path)
+		}
+		expectedNum, isNum := toFloat64(c.Expected)
+		if !isNum {
+			return fmt.Errorf("expected value should be number")
+		}
+		if !ok(numVal, expectedNum) {
+			return fmt.Errorf("%s should be %s %v, got %v", c.Path, relation, expectedNum, numVal)
+		}
+		return nil
+	}
+}
+
+// compareLength 创建长度比较操作符，relation 用于错误信息
+func compareLength(relation string, ok func(n, want int64) bool) AssertionFunc {
+	return func(c AssertionContext) error {
+		length, isLen := valueLength(c.Actual)
+		if !isLen {
+			return fmt.Errorf("%s should be array, object or string, got %T", c.Path, c.Actual)
+		}
+		want, isInt := toInt64(c.Expected)
+		if !isInt {
+			return fmt.Errorf("expected length should be integer")
+		}
+		if !ok(int64(length), want) {
+			return fmt.Errorf("%s should have length %s %d, got %d", c.Path, relation, want, length)
+		}
+		return nil
+	}
+}
+
+// assertBetween between 操作符
+func assertBetween(c AssertionContext) error {
+	bounds, ok := c.Expected.([]any)
+	if !ok || len(bounds) != 2 {
+		return fmt.Errorf("between expects value [min, max], got %v", c.Expected)
+	}
+	lo, okLo := toFloat64(bounds[0])
+	hi, okHi := toFloat64(bounds[1])
+	if !okLo || !okHi {
+		return fmt.Errorf("between bounds should be numbers, got %v", c.Expected)
+	}
+	n, ok := toFloat64(c.Actual)
+	if !ok {
+		return fmt.Errorf("%s should be number", c.Path)
+	}
+	if n < lo || n > hi {
+		return fmt.Errorf("%s should be between %v and %v, got %v", c.Path, lo, hi, n)
+	}
+	return nil
+}
+
+// assertNotContains notContains 操作符
+func assertNotContains(c AssertionContext) error {
+	if assertContains(c) == nil {
+		return fmt.Errorf("%s should not contain %v, got %v", c.Path, c.Expected, c.Actual)
+	}
+	return nil
+}
+
+// assertEndsWith endsWith 操作符
+func assertEndsWith(c AssertionContext) error {
+	str := fmt.Sprint(c.Actual)
+	suffix := fmt.Sprint(c.Expected)
+	if !strings.HasSuffix(str, suffix) {
+		return fmt.Errorf("%s should end with %s, got %s", c.Path, suffix, str)
+	}
+	return nil
+}
+
+// assertMatches matches 操作符
+func assertMatches(c AssertionContext) error {
+	re, err := regexp.Compile(fmt.Sprint(c.Expected))
+	if err != nil {
+		return fmt.Errorf("invalid regular expression %v: %w", c.Expected, err)
+	}
+	if c.Actual == nil || !re.MatchString(fmt.Sprint(c.Actual)) {
+		return fmt.Errorf("%s should match %s, got %v", c.Path, re, c.Actual)
+	}
+	return nil
+}
+
+// assertIn in 操作符
+func assertIn(c AssertionContext) error {
+	options, ok := c.Expected.([]any)
+	if !ok {
+		return fmt.Errorf("in expects an array value, got %v", c.Expected)
+	}
+	for _, option := range options {
+		if valuesEqual(c.Actual, option) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s should be one of %v, got %v", c.Path, options, c.Actual)
+}
+
+// assertNotIn notIn 操作符
+func assertNotIn(c AssertionContext) error {
+	options, ok := c.Expected.([]any)
+	if !ok {
+		return fmt.Errorf("notIn expects an array value, got %v", c.Expected)
+	}
+	for _, option := range options {
+		if valuesEqual(c.Actual, option) {
+			return fmt.Errorf("%s should not be one of %v, got %v", c.Path, options, c.Actual)
+		}
+	}
+	return nil
+}
+
+// assertIsNull isNull 操作符
+func assertIsNull(c AssertionContext) error {
+	if !c.Found {
+		return fmt.Errorf("%s should be null, but does not exist", c.Path)
+	}
+	if c.Actual != nil {
+		return fmt.Errorf("%s should be null, got %v", c.Path, c.Actual)
+	}
+	return nil
+}
+
+// assertIsEmpty isEmpty 操作符
+func assertIsEmpty(c AssertionContext) error {
+	if c.Actual == nil || c.Actual == "" {
+		return nil
+	}
+	if n, ok := valueLength(c.Actual); ok && n == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s should be empty, got %v", c.Path, c.Actual)
+}
+
+// assertExists exists 操作符
+func assertExists(c AssertionContext) error {
+	if !c.Found {
+		return fmt.Errorf("%s should exist", c.Path)
+	}
+	return nil
+}
+
+// assertNotExists notExists 操作符
+func assertNotExists(c AssertionContext) error {
+	if c.Found {
+		return fmt.Errorf("%s should not exist, got %v", c.Path, c.Actual)
+	}
+	return nil
+}
+
+// assertType type 操作符，integer 也视为 number，bool 视为 boolean
+func assertType(c AssertionContext) error {
+	want := fmt.Sprint(c.Expected)
+	if want == "bool" {
+		want = "boolean"
+	}
+	switch want {
+	case "string", "number", "integer", "boolean", "object", "array", "null":
+	default:
+		return fmt.Errorf("unknown type %q: expected string, number, integer, boolean, object, array or null", want)
+	}
+	if !c.Found {
+		return fmt.Errorf("%s should be %s, but does not exist", c.Path, want)
+	}
+	got := jsonType(c.Actual)
+	if got == want || (want == "number" && got == "integer") {
+		return nil
+	}
+	return fmt.Errorf("%s should be %s, got %s", c.Path, want, got)
+}
+
+// assertFormat 创建按 validFormat 校验字符串格式的操作符
+func assertFormat(format, name string) AssertionFunc {
+	return func(c AssertionContext) error {
+		if s, ok := c.Actual.(string); !ok || !validFormat(format, s) {
+			return fmt.Errorf("%s should be %s, got %v", c.Path, name, c.Actual)
+		}
+		return nil
+	}
+}
+
+// assertISO8601 isISO8601 操作符
+func assertISO8601(c AssertionContext) error {
+	if s, ok := c.Actual.(string); ok && (validFormat("date-time", s) || validFormat("date", s)) {
+		return nil
+	}
+	return fmt.Errorf("%s should be ISO 8601 date or date-time, got %v", c.Path, c.Actual)
+}
+
+// assertDeepEquals deepEquals 操作符
+func assertDeepEquals(c AssertionContext) error {
+	if !schemaEqual(c.Actual, c.Expected) {
+		return fmt.Errorf("%s should deep equal %s, got %s", c.Path, jsonString(c.Expected), jsonString(c.Actual))
+	}
+	return nil
+}
+
+// jsonString 将值格式化为 JSON，用于错误信息
+func jsonString(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// assertEach each 操作符：对数组的每个元素执行子断言
+func assertEach(c AssertionContext) error {
+	spec, ok := c.Expected.(map[string]any)
+	if !ok {
+		return fmt.Errorf("each expects value {path, operator, value}, got %v", c.Expected)
+	}
+	name := fmt.Sprint(spec["operator"])
+	fn, ok := lookupOperator(name)
+	if !ok {
+		return fmt.Errorf("unknown operator: %s", name)
+	}
+	items, ok := c.Actual.([]any)
+	if !ok {
+		return fmt.Errorf("%s should be array", c.Path)
+	}
+
+	subPath, _ := spec["path"].(string)
+	for i, item := range items {
+		sub := AssertionContext{Path: fmt.Sprintf("%s[%d]", c.Path, i), Actual: item, Found: true, Expected: spec["value"]}
+		if subPath != "" {
+			value, found, err := resolvePath(subPath, item)
+			if err != nil {
+				return err
+			}
+			sub.Path += "." + strings.TrimPrefix(subPath, "$.")
+			sub.Actual, sub.Found = value, found
+		}
+		if err := fn(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
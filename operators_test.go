@@ -0,0 +1,130 @@
+package apitest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRegisterOperator(t *testing.T) {
+	RegisterOperator("isSnowflakeID", "19 位雪花 ID", func(c AssertionContext) error {
+		if id := fmt.Sprint(c.Actual); len(id) != 19 || strings.Trim(id, "0123456789") != "" {
+			return fmt.Errorf("%s should be a snowflake id, got %v", c.Path, c.Actual)
+		}
+		return nil
+	})
+	RegisterOperator("isMissing", "字段不存在", func(c AssertionContext) error {
+		if c.Found {
+			return errors.New(c.Path + " should not exist")
+		}
+		return nil
+	})
+
+	runner := &TestRunner{variables: Variables{"currency": "EUR"}}
+	data := map[string]any{"id": int64(1790000000000000001), "short": int64(42), "currency": "EUR"}
+
+	tests := []struct {
+		assertion Assertion
+		wantErr   string
+	}{
+		{Assertion{Path: "id", Operator: "isSnowflakeID"}, ""},
+		{Assertion{Path: "short", Operator: "isSnowflakeID"}, "assertion failed: short should be a snowflake id, got 42"},
+		{Assertion{Path: "deleted_at", Operator: "isMissing"}, ""},
+		{Assertion{Path: "currency", Operator: "isMissing"}, "assertion failed: currency should not exist"},
+		{Assertion{Path: "currency", Operator: "equals", Value: "{{currency}}"}, ""},
+		{Assertion{Path: "short", Operator: "greaterThanOrEqual", Value: 50}, "assertion failed: short should be >= 50, got 42"},
+		{Assertion{Path: "id", Operator: "isMoneyAmount"}, "unknown operator: isMoneyAmount"},
+	}
+	for _, tt := range tests {
+		err := runner.executeAssertion(tt.assertion, data)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%s %s: unexpected error %v", tt.assertion.Path, tt.assertion.Operator, err)
+		}
+		if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+			t.Errorf("%s %s: expected error %q, got %v", tt.assertion.Path, tt.assertion.Operator, tt.wantErr, err)
+		}
+	}
+
+	infos := make(map[string]OperatorInfo)
+	for _, info := range Operators() {
+		infos[info.Name] = info
+	}
+	if info := infos["isSnowflakeID"]; info.Builtin || info.Description != "19 位雪花 ID" {
+		t.Errorf("Expected custom operator listed, got %+v", info)
+	}
+	if !infos["equals"].Builtin {
+		t.Errorf("Expected built-in equals listed, got %+v", infos["equals"])
+	}
+}
+
+func TestBuiltinOperators(t *testing.T) {
+	runner := &TestRunner{variables: Variables{"max": 100, "role": "admin"}}
+	data, _ := decodeJSON([]byte(`{
+		"id": "3f1c2a9e-8b7d-4c6e-9f0a-1b2c3d4e5f60",
+		"name": "order-2024.csv",
+		"price": 42.5,
+		"count": 3,
+		"role": "admin",
+		"deleted_at": null,
+		"tags": [],
+		"created_at": "2024-05-01T10:00:00+08:00",
+		"day": "2024-05-01",
+		"meta": {"a": 1, "b": [1, 2]},
+		"items": [{"id": 1, "qty": 2}, {"id": 2, "qty": 5}]
+	}`))
+
+	pass := []Assertion{
+		{Path: "count", Operator: "lessThanOrEqual", Value: 3},
+		{Path: "price", Operator: "between", Value: []any{10, "{{max}}"}},
+		{Path: "name", Operator: "notContains", Value: "xlsx"},
+		{Path: "items[*].id", Operator: "notContains", Value: 3},
+		{Path: "name", Operator: "endsWith", Value: ".csv"},
+		{Path: "name", Operator: "matches", Value: `^order-\d{4}\.csv$`},
+		{Path: "role", Operator: "in", Value: []any{"user", "{{role}}"}},
+		{Path: "count", Operator: "notIn", Value: []any{1, 2}},
+		{Path: "name", Operator: "minLength", Value: 5},
+		{Path: "items", Operator: "maxLength", Value: 2},
+		{Path: "deleted_at", Operator: "isNull"},
+		{Path: "deleted_at", Operator: "exists"},
+		{Path: "missing", Operator: "notExists"},
+		{Path: "tags", Operator: "isEmpty"},
+		{Path: "count", Operator: "type", Value: "number"},
+		{Path: "count", Operator: "type", Value: "integer"},
+		{Path: "meta", Operator: "type", Value: "object"},
+		{Path: "deleted_at", Operator: "type", Value: "null"},
+		{Path: "id", Operator: "isUUID"},
+		{Path: "created_at", Operator: "isISO8601"},
+		{Path: "day", Operator: "isISO8601"},
+		{Path: "meta", Operator: "deepEquals", Value: map[string]any{"b": []any{1, 2}, "a": 1}},
+		{Path: "items", Operator: "each", Value: map[string]any{"path": "qty", "operator": "greaterThan", "value": 1}},
+		{Path: "items[*].id", Operator: "each", Value: map[string]any{"operator": "in", "value": []any{1, 2}}},
+	}
+	for _, a := range pass {
+		if err := runner.executeAssertion(a, data); err != nil {
+			t.Errorf("%s %s %v: unexpected error %v", a.Path, a.Operator, a.Value, err)
+		}
+	}
+
+	fail := []struct {
+		assertion Assertion
+		wantErr   string
+	}{
+		{Assertion{Path: "price", Operator: "between", Value: []any{50, 60}}, "price should be between 50 and 60, got 42.5"},
+		{Assertion{Path: "missing", Operator: "isNull"}, "missing should be null, but does not exist"},
+		{Assertion{Path: "deleted_at", Operator: "notExists"}, "deleted_at should not exist"},
+		{Assertion{Path: "missing", Operator: "exists"}, "missing should exist"},
+		{Assertion{Path: "price", Operator: "type", Value: "integer"}, "price should be integer, got number"},
+		{Assertion{Path: "name", Operator: "matches", Value: "["}, "invalid regular expression"},
+		{Assertion{Path: "name", Operator: "isUUID"}, "name should be UUID"},
+		{Assertion{Path: "meta", Operator: "deepEquals", Value: map[string]any{"a": 1}}, `meta should deep equal {"a":1}, got {"a":1,"b":[1,2]}`},
+		{Assertion{Path: "items", Operator: "each", Value: map[string]any{"path": "qty", "operator": "lessThan", "value": 3}}, "items[1].qty should be less than 3, got 5"},
+		{Assertion{Path: "items", Operator: "each", Value: map[string]any{"operator": "bogus"}}, "unknown operator: bogus"},
+	}
+	for _, tt := range fail {
+		err := runner.executeAssertion(tt.assertion, data)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s %s: expected error containing %q, got %v", tt.assertion.Path, tt.assertion.Operator, tt.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,559 @@
+package apitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// postmanCollection Postman v2.1 集合
+type postmanCollection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanVariable `json:"variable"`
+	Auth     *postmanAuth      `json:"auth"`
+	Event    []postmanEvent    `json:"event"`
+}
+
+// postmanItem 文件夹（包含 item）或请求（包含 request）
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []postmanItem   `json:"item"`
+	Request *postmanRequest `json:"request"`
+	Event   []postmanEvent  `json:"event"`
+	Auth    *postmanAuth    `json:"auth"`
+}
+
+// postmanRequest Postman 请求
+type postmanRequest struct {
+	Method string            `json:"method"`
+	Header []postmanVariable `json:"header"`
+	URL    json.RawMessage   `json:"url"` // 字符串或 postmanURL
+	Body   *postmanBody      `json:"body"`
+	Auth   *postmanAuth      `json:"auth"`
+}
+
+// postmanURL 结构化的 URL
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Query    []postmanVariable `json:"query"`
+	Variable []postmanVariable `json:"variable"`
+}
+
+// postmanBody 请求体
+type postmanBody struct {
+	Mode string `json:"mode"`
+	Raw  string `json:"raw"`
+}
+
+// postmanAuth 认证配置
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	Bearer []postmanVariable `json:"bearer"`
+}
+
+// postmanEvent 脚本事件（prerequest、test）
+type postmanEvent struct {
+	Listen string `json:"listen"`
+	Script struct {
+		Exec json.RawMessage `json:"exec"` // 字符串或字符串数组
+	} `json:"script"`
+}
+
+// postmanVariable 键值对：集合变量、环境变量、请求头、查询参数、路径变量
+type postmanVariable struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Disabled bool   `json:"disabled"`
+	Enabled  *bool  `json:"enabled"` // 环境文件使用 enabled
+}
+
+// active 是否启用
+func (v postmanVariable) active() bool {
+	return !v.Disabled && (v.Enabled == nil || *v.Enabled)
+}
+
+// value 返回字符串形式的值
+func (v postmanVariable) value() string {
+	if v.Value == nil {
+		return ""
+	}
+	return fmt.Sprint(v.Value)
+}
+
+// postmanEnvironment Postman 环境文件
+type postmanEnvironment struct {
+	Name   string            `json:"name"`
+	Values []postmanVariable `json:"values"`
+}
+
+// ImportPostman 将 Postman v2.1 集合（以及可选的环境文件）转换为测试套件
+//
+// 文件夹转换为场景（嵌套文件夹以 " / " 连接），请求转换为测试用例；集合变量和环境变量转换为 Variables，
+// 以 {{baseUrl}} 等变量或绝对地址开头的 URL 前缀作为 base_url。test 脚本中的
+// pm.response.to.have.status、pm.environment.set 和简单的 pm.expect 断言分别转换为 expect.status_code、
+// save 和 assertions。无法转换的内容作为警告返回。environment 为空时只转换集合
+func ImportPostman(collection, environment []byte) (*TestSuite, []string, error) {
+	var c postmanCollection
+	if err := json.Unmarshal(collection, &c); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse postman collection: %w", err)
+	}
+	if c.Info.Schema != "" && !strings.Contains(c.Info.Schema, "v2.1") {
+		return nil, nil, fmt.Errorf("unsupported postman collection schema '%s', only v2.1 is supported", c.Info.Schema)
+	}
+
+	im := &postmanImporter{
+		suite: &TestSuite{
+			Suite:     SuiteConfig{Name: c.Info.Name},
+			Variables: Variables{},
+		},
+	}
+
+	for _, v := range c.Variable {
+		if v.active() {
+			im.suite.Variables[v.Key] = v.value()
+		}
+	}
+	if len(environment) > 0 {
+		var env postmanEnvironment
+		if err := json.Unmarshal(environment, &env); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse postman environment: %w", err)
+		}
+		// 环境变量优先于集合变量
+		for _, v := range env.Values {
+			if v.active() {
+				im.suite.Variables[v.Key] = v.value()
+			}
+		}
+	}
+
+	for _, e := range c.Event {
+		if script := e.lines(); len(script) > 0 {
+			im.warn("collection", "%s script is not translated", e.Listen)
+		}
+	}
+
+	root := Scenario{Name: c.Info.Name}
+	for _, item := range c.Item {
+		if item.Request != nil {
+			root.TestCases = append(root.TestCases, im.testCase(root.Name, item, c.Auth))
+		}
+	}
+	if len(root.TestCases) > 0 {
+		im.suite.Scenarios = append(im.suite.Scenarios, root)
+	}
+	for _, item := range c.Item {
+		if item.Request == nil {
+			im.folder("", item, c.Auth)
+		}
+	}
+
+	if im.base != "" && im.suite.Suite.BaseURL == "" {
+		im.warn("collection", "variable {{%s}} has no value, set suite.base_url manually", im.base)
+	}
+	if len(im.suite.Variables) == 0 {
+		im.suite.Variables = nil
+	}
+	return im.suite, im.warnings, nil
+}
+
+// postmanImporter 转换过程中的状态
+type postmanImporter struct {
+	suite    *TestSuite
+	base     string // 作为 base_url 的变量名，例如 baseUrl
+	warnings []string
+}
+
+// warn 记录无法转换的内容
+func (im *postmanImporter) warn(where, format string, args ...any) {
+	im.warnings = append(im.warnings, where+": "+fmt.Sprintf(format, args...))
+}
+
+// folder 将文件夹转换为场景，子文件夹递归转换为独立场景
+func (im *postmanImporter) folder(parent string, item postmanItem, auth *postmanAuth) {
+	name := item.Name
+	if parent != "" {
+		name = parent + " / " + item.Name
+	}
+	if item.Auth != nil {
+		auth = item.Auth
+	}
+	for _, e := range item.Event {
+		if script := e.lines(); len(script) > 0 {
+			im.warn(name, "folder %s script is not translated", e.Listen)
+		}
+	}
+
+	scenario := Scenario{Name: name}
+	for _, child := range item.Item {
+		if child.Request != nil {
+			scenario.TestCases = append(scenario.TestCases, im.testCase(name, child, auth))
+		}
+	}
+	if len(scenario.TestCases) > 0 {
+		im.suite.Scenarios = append(im.suite.Scenarios, scenario)
+	}
+	for _, child := range item.Item {
+		if child.Request == nil {
+			im.folder(name, child, auth)
+		}
+	}
+}
+
+// testCase 将请求转换为测试用例
+func (im *postmanImporter) testCase(scenario string, item postmanItem, auth *postmanAuth) TestCase {
+	where := scenario + "/" + item.Name
+	req := item.Request
+	tc := TestCase{
+		Name:    item.Name,
+		Request: RequestConfig{Method: strings.ToUpper(req.Method)},
+	}
+	if tc.Request.Method == "" {
+		tc.Request.Method = "GET"
+	}
+
+	im.url(where, req.URL, &tc.Request)
+
+	for _, h := range req.Header {
+		if h.active() {
+			if tc.Request.Headers == nil {
+				tc.Request.Headers = make(map[string]string)
+			}
+			tc.Request.Headers[h.Key] = h.value()
+		}
+	}
+
+	if req.Auth != nil {
+		auth = req.Auth
+	}
+	if auth != nil {
+		im.auth(where, auth, &tc.Request)
+	}
+
+	if req.Body != nil {
+		im.body(where, req.Body, &tc.Request)
+	}
+
+	for _, e := range item.Event {
+		switch e.Listen {
+		case "test":
+			im.testScript(where, e.lines(), &tc)
+		default:
+			if script := e.lines(); len(script) > 0 {
+				im.warn(where, "%s script is not translated", e.Listen)
+			}
+		}
+	}
+	return tc
+}
+
+// postmanBaseVar 匹配 URL 开头的变量，例如 {{baseUrl}}/users
+var postmanBaseVar = regexp.MustCompile(`^\{\{([^{}]+)\}\}`)
+
+// postmanPathVar 匹配路径变量，例如 /users/:id
+var postmanPathVar = regexp.MustCompile(`/:([A-Za-z_][A-Za-z0-9_]*)`)
+
+// url 解析请求地址，拆分出 base_url、路径和查询参数
+func (im *postmanImporter) url(where string, raw json.RawMessage, cfg *RequestConfig) {
+	var u postmanURL
+	if err := json.Unmarshal(raw, &u.Raw); err != nil {
+		if err := json.Unmarshal(raw, &u); err != nil {
+			im.warn(where, "invalid url: %v", err)
+			return
+		}
+	}
+
+	address, rawQuery, hasQuery := strings.Cut(u.Raw, "?")
+	if len(u.Query) > 0 {
+		for _, q := range u.Query {
+			if q.active() {
+				if cfg.Query == nil {
+					cfg.Query = make(map[string]string)
+				}
+				cfg.Query[q.Key] = q.value()
+			}
+		}
+	} else if hasQuery {
+		for _, pair := range strings.Split(rawQuery, "&") {
+			if pair == "" {
+				continue
+			}
+			key, value, _ := strings.Cut(pair, "=")
+			if cfg.Query == nil {
+				cfg.Query = make(map[string]string)
+			}
+			cfg.Query[key] = value
+		}
+	}
+
+	path := address
+	if m := postmanBaseVar.FindStringSubmatch(address); m != nil {
+		path = strings.TrimPrefix(address, m[0])
+		switch {
+		case im.base == "":
+			im.base = m[1]
+			if value, ok := im.suite.Variables[m[1]].(string); ok {
+				im.suite.Suite.BaseURL = strings.TrimSuffix(value, "/")
+			}
+		case im.base != m[1]:
+			im.warn(where, "url starts with {{%s}} but the suite base_url comes from {{%s}}", m[1], im.base)
+		}
+	} else if parsed, err := url.Parse(address); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+		origin := parsed.Scheme + "://" + parsed.Host
+		path = strings.TrimPrefix(address, origin)
+		switch {
+		case im.suite.Suite.BaseURL == "" && im.base == "":
+			im.suite.Suite.BaseURL = origin
+		case im.suite.Suite.BaseURL != origin:
+			im.warn(where, "url host %s differs from the suite base_url", origin)
+		}
+	}
+
+	// 路径变量 :id 转换为 {{id}}，有值时直接使用该值
+	values := make(map[string]string)
+	for _, v := range u.Variable {
+		values[v.Key] = v.value()
+	}
+	path = postmanPathVar.ReplaceAllStringFunc(path, func(s string) string {
+		name := s[2:]
+		if value := values[name]; value != "" {
+			return "/" + value
+		}
+		return "/{{" + name + "}}"
+	})
+
+	if path == "" || !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	cfg.Path = path
+}
+
+// auth 转换认证配置，目前支持 bearer 和 noauth
+func (im *postmanImporter) auth(where string, auth *postmanAuth, cfg *RequestConfig) {
+	switch auth.Type {
+	case "noauth", "":
+	case "bearer":
+		for _, v := range auth.Bearer {
+			if v.Key == "token" {
+				if cfg.Headers == nil {
+					cfg.Headers = make(map[string]string)
+				}
+				cfg.Headers["Authorization"] = "Bearer " + v.value()
+			}
+		}
+	default:
+		im.warn(where, "auth type '%s' is not translated", auth.Type)
+	}
+}
+
+// body 转换请求体，目前支持 JSON 对象
+func (im *postmanImporter) body(where string, body *postmanBody, cfg *RequestConfig) {
+	switch body.Mode {
+	case "raw":
+		if strings.TrimSpace(body.Raw) == "" {
+			return
+		}
+		data, err := decodeJSON([]byte(postmanJSONVars(body.Raw)))
+		obj, ok := data.(map[string]any)
+		if err != nil || !ok {
+			im.warn(where, "raw body is not a JSON object and is not translated")
+			return
+		}
+		cfg.Body = obj
+		if cfg.Headers == nil {
+			cfg.Headers = make(map[string]string)
+		}
+		if _, ok := cfg.Headers["Content-Type"]; !ok {
+			cfg.Headers["Content-Type"] = "application/json"
+		}
+	case "":
+	default:
+		im.warn(where, "body mode '%s' is not translated", body.Mode)
+	}
+}
+
+// postmanUnquotedVar 匹配 JSON 中未加引号的变量，例如 {"id": {{userId}}}
+var postmanUnquotedVar = regexp.MustCompile(`([:\[,]\s*)\{\{([^{}"]+)\}\}`)
+
+// postmanJSONVars 为未加引号的变量加上标记引号，使请求体可以按 JSON 解析
+func postmanJSONVars(raw string) string {
+	return postmanUnquotedVar.ReplaceAllString(raw, `$1"{{$2}}"`)
+}
+
+// lines 返回脚本的非空行
+func (e postmanEvent) lines() []string {
+	var exec []string
+	if err := json.Unmarshal(e.Script.Exec, &exec); err != nil {
+		var s string
+		json.Unmarshal(e.Script.Exec, &s)
+		exec = strings.Split(s, "\n")
+	}
+
+	var lines []string
+	for _, line := range exec {
+		for _, l := range strings.Split(line, "\n") {
+			if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, "//") {
+				lines = append(lines, l)
+			}
+		}
+	}
+	return lines
+}
+
+// Postman test 脚本中可以转换的语句
+var (
+	postmanTestWrapper = regexp.MustCompile(`^pm\.test\(.*(function\s*\(\)|\(\)\s*=>)\s*\{$|^\}\)?\)?;?$`)
+	postmanJSONAlias   = regexp.MustCompile(`^(?:var|let|const)\s+(\w+)\s*=\s*(?:pm\.response\.json\(\)|JSON\.parse\(responseBody\));?$`)
+	postmanStatus      = regexp.MustCompile(`^pm\.response\.to\.have\.status\((\d{3})\);?$`)
+	postmanStatusEql   = regexp.MustCompile(`^pm\.expect\(pm\.response\.code\)\.to\.(?:be\.)?(?:eql|equal|eq)\((\d{3})\);?$`)
+	postmanSet         = regexp.MustCompile(`^(?:pm\.(?:environment|collectionVariables|globals|variables)\.set|postman\.set(?:Environment|Global)Variable)\(\s*["']([^"']+)["']\s*,\s*(.+?)\s*\);?$`)
+	postmanExpect      = regexp.MustCompile(`^pm\.expect\((.+?)\)\.to\.((?:not\.)?(?:be\.|have\.|deep\.|at\.)*)(\w+)(?:\((.*)\))?;?$`)
+	postmanPathSegment = regexp.MustCompile(`\[["'](\w+)["']\]`)
+	postmanPathRest    = regexp.MustCompile(`^(\.\w+|\[\d+\])+$`)
+)
+
+// postmanOperators Chai 断言到断言操作符的映射
+var postmanOperators = map[string]string{
+	"eql":      "equals",
+	"equal":    "equals",
+	"eq":       "equals",
+	"include":  "contains",
+	"contain":  "contains",
+	"above":    "greaterThan",
+	"least":    "greaterThanOrEqual",
+	"below":    "lessThan",
+	"lengthOf": "length",
+	"length":   "length",
+	"empty":    "notEmpty", // 仅支持 to.not.be.empty
+	"an":       "isArray",  // 仅支持 to.be.an("array")
+	"a":        "isArray",
+}
+
+// testScript 转换 test 脚本
+func (im *postmanImporter) testScript(where string, lines []string, tc *TestCase) {
+	aliases := map[string]bool{"pm.response.json()": true}
+
+	for _, line := range lines {
+		if postmanTestWrapper.MatchString(line) {
+			continue
+		}
+		if m := postmanJSONAlias.FindStringSubmatch(line); m != nil {
+			aliases[m[1]] = true
+			continue
+		}
+		if m := postmanStatus.FindStringSubmatch(line); m != nil {
+			fmt.Sscan(m[1], &tc.Expect.StatusCode)
+			continue
+		}
+		if m := postmanStatusEql.FindStringSubmatch(line); m != nil {
+			fmt.Sscan(m[1], &tc.Expect.StatusCode)
+			continue
+		}
+		if m := postmanSet.FindStringSubmatch(line); m != nil {
+			if path, ok := postmanResponsePath(m[2], aliases); ok {
+				if tc.Save == nil {
+					tc.Save = make(map[string]string)
+				}
+				tc.Save[m[1]] = path
+				continue
+			}
+		}
+		if m := postmanExpect.FindStringSubmatch(line); m != nil {
+			if assertion, ok := postmanAssertion(m, aliases); ok {
+				tc.Expect.Assertions = append(tc.Expect.Assertions, assertion)
+				continue
+			}
+		}
+		im.warn(where, "untranslated test script line: %s", line)
+	}
+}
+
+// postmanResponsePath 将 jsonData.data.items[0].id 之类的表达式转换为响应体路径
+func postmanResponsePath(expr string, aliases map[string]bool) (string, bool) {
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	for _, alias := range names {
+		rest, ok := strings.CutPrefix(expr, alias)
+		if !ok {
+			continue
+		}
+		rest = postmanPathSegment.ReplaceAllString(rest, ".$1")
+		if rest == "" {
+			return "$", true
+		}
+		if !postmanPathRest.MatchString(rest) {
+			return "", false
+		}
+		return strings.TrimPrefix(rest, "."), true
+	}
+	return "", false
+}
+
+// postmanAssertion 将 pm.expect(...).to.xxx(...) 转换为断言
+func postmanAssertion(m []string, aliases map[string]bool) (Assertion, bool) {
+	subject, chain, method, arg := m[1], m[2], m[3], strings.TrimSpace(m[4])
+
+	path, ok := postmanResponsePath(subject, aliases)
+	if !ok {
+		return Assertion{}, false
+	}
+	negated := strings.HasPrefix(chain, "not.")
+	operator, ok := postmanOperators[method]
+	if !ok {
+		return Assertion{}, false
+	}
+
+	switch {
+	case method == "empty":
+		if !negated {
+			return Assertion{}, false
+		}
+		return Assertion{Path: path, Operator: operator}, true
+	case method == "an" || method == "a":
+		if negated || strings.Trim(arg, `"'`) != "array" {
+			return Assertion{}, false
+		}
+		return Assertion{Path: path, Operator: operator}, true
+	case negated:
+		if operator != "equals" {
+			return Assertion{}, false
+		}
+		operator = "notEquals"
+	}
+
+	value, ok := postmanLiteral(arg)
+	if !ok {
+		return Assertion{}, false
+	}
+	return Assertion{Path: path, Operator: operator, Value: value}, true
+}
+
+// postmanLiteral 解析 JavaScript 字面量（数字、字符串、布尔、null）
+func postmanLiteral(s string) (any, bool) {
+	if strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") && len(s) >= 2 {
+		inner := s[1 : len(s)-1]
+		if strings.Contains(inner, `"`) {
+			return inner, true
+		}
+		s = `"` + inner + `"`
+	}
+	value, err := decodeJSON([]byte(s))
+	if err != nil || s == "" {
+		return nil, false
+	}
+	switch value.(type) {
+	case map[string]any, []any:
+		return nil, false
+	}
+	return value, true
+}
@@ -0,0 +1,158 @@
+package apitest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPostmanCollection = `{
+  "info": {
+    "name": "User API",
+    "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+  },
+  "auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}", "type": "string"}]},
+  "variable": [{"key": "baseUrl", "value": "http://localhost:9000/"}, {"key": "page", "value": "1"}],
+  "item": [
+    {
+      "name": "Health",
+      "request": {"method": "GET", "url": "{{baseUrl}}/health?verbose=true"}
+    },
+    {
+      "name": "Users",
+      "item": [
+        {
+          "name": "Create User",
+          "event": [{
+            "listen": "test",
+            "script": {"exec": [
+              "pm.test(\"created\", function () {",
+              "    pm.response.to.have.status(201);",
+              "});",
+              "var jsonData = pm.response.json();",
+              "pm.environment.set(\"user_id\", jsonData.data.id);",
+              "pm.collectionVariables.set('user_name', jsonData[\"data\"].name);",
+              "pm.expect(jsonData.code).to.eql(0);",
+              "pm.expect(jsonData.data.tags).to.include('new');",
+              "pm.expect(jsonData.data.roles).to.be.an('array');",
+              "pm.expect(pm.response.headers.get('X-Id')).to.exist;"
+            ]}
+          }],
+          "request": {
+            "method": "POST",
+            "header": [
+              {"key": "Content-Type", "value": "application/json"},
+              {"key": "X-Debug", "value": "1", "disabled": true}
+            ],
+            "body": {"mode": "raw", "raw": "{\"name\": \"{{name}}\", \"age\": {{age}}, \"id\": 1234567890123456789}"},
+            "url": {
+              "raw": "{{baseUrl}}/users",
+              "host": ["{{baseUrl}}"],
+              "path": ["users"]
+            }
+          }
+        },
+        {
+          "name": "Admin",
+          "item": [{
+            "name": "Get User",
+            "event": [{"listen": "prerequest", "script": {"exec": "console.log('hi')"}}],
+            "request": {
+              "method": "GET",
+              "auth": {"type": "basic"},
+              "url": {
+                "raw": "{{baseUrl}}/users/:id?page={{page}}",
+                "query": [{"key": "page", "value": "{{page}}"}, {"key": "debug", "value": "1", "disabled": true}],
+                "variable": [{"key": "id", "value": "{{user_id}}"}]
+              },
+              "body": {"mode": "formdata"}
+            }
+          }]
+        }
+      ]
+    }
+  ]
+}`
+
+const testPostmanEnvironment = `{
+  "name": "local",
+  "values": [
+    {"key": "baseUrl", "value": "http://localhost:8080", "enabled": true},
+    {"key": "token", "value": "secret", "enabled": true},
+    {"key": "unused", "value": "x", "enabled": false}
+  ]
+}`
+
+func TestImportPostman(t *testing.T) {
+	suite, warnings, err := ImportPostman([]byte(testPostmanCollection), []byte(testPostmanEnvironment))
+	if err != nil {
+		t.Fatalf("ImportPostman failed: %v", err)
+	}
+
+	if suite.Suite.Name != "User API" || suite.Suite.BaseURL != "http://localhost:8080" {
+		t.Errorf("Unexpected suite config: %+v", suite.Suite)
+	}
+	wantVars := Variables{"baseUrl": "http://localhost:8080", "page": "1", "token": "secret"}
+	if !reflect.DeepEqual(suite.Variables, wantVars) {
+		t.Errorf("Variables = %v, want %v", suite.Variables, wantVars)
+	}
+
+	var scenarios []string
+	for _, s := range suite.Scenarios {
+		scenarios = append(scenarios, s.Name)
+	}
+	if strings.Join(scenarios, "|") != "User API|Users|Users / Admin" {
+		t.Fatalf("Unexpected scenarios: %v", scenarios)
+	}
+
+	health := suite.Scenarios[0].TestCases[0]
+	if health.Request.Path != "/health" || health.Request.Query["verbose"] != "true" {
+		t.Errorf("Unexpected health request: %+v", health.Request)
+	}
+	if health.Request.Headers["Authorization"] != "Bearer {{token}}" {
+		t.Errorf("Expected collection bearer auth to be inherited, got %v", health.Request.Headers)
+	}
+
+	create := suite.Scenarios[1].TestCases[0]
+	if create.Expect.StatusCode != 201 {
+		t.Errorf("Expected status 201, got %d", create.Expect.StatusCode)
+	}
+	wantSave := map[string]string{"user_id": "data.id", "user_name": "data.name"}
+	if !reflect.DeepEqual(create.Save, wantSave) {
+		t.Errorf("Save = %v, want %v", create.Save, wantSave)
+	}
+	wantAssertions := []Assertion{
+		{Path: "code", Operator: "equals", Value: int64(0)},
+		{Path: "data.tags", Operator: "contains", Value: "new"},
+		{Path: "data.roles", Operator: "isArray"},
+	}
+	if !reflect.DeepEqual(create.Expect.Assertions, wantAssertions) {
+		t.Errorf("Assertions = %+v, want %+v", create.Expect.Assertions, wantAssertions)
+	}
+	wantBody := map[string]any{"name": "{{name}}", "age": "{{age}}", "id": int64(1234567890123456789)}
+	if !reflect.DeepEqual(create.Request.Body, wantBody) {
+		t.Errorf("Body = %#v, want %#v", create.Request.Body, wantBody)
+	}
+	if _, ok := create.Request.Headers["X-Debug"]; ok {
+		t.Error("Disabled header should be skipped")
+	}
+
+	get := suite.Scenarios[2].TestCases[0]
+	if get.Request.Path != "/users/{{user_id}}" || !reflect.DeepEqual(get.Request.Query, map[string]string{"page": "{{page}}"}) {
+		t.Errorf("Unexpected get request: %+v", get.Request)
+	}
+
+	wantWarnings := []string{
+		"Users/Create User: untranslated test script line: pm.expect(pm.response.headers.get('X-Id')).to.exist;",
+		"Users / Admin/Get User: auth type 'basic' is not translated",
+		"Users / Admin/Get User: body mode 'formdata' is not translated",
+		"Users / Admin/Get User: prerequest script is not translated",
+	}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("Warnings:\n got %q\nwant %q", warnings, wantWarnings)
+	}
+
+	if _, _, err := ImportPostman([]byte(`{"info": {"schema": "https://schema.getpostman.com/json/collection/v2.0.0/collection.json"}}`), nil); err == nil {
+		t.Error("Expected v2.0 collection to be rejected")
+	}
+}
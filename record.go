@@ -0,0 +1,449 @@
+package apitest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordedExchange 代理记录的一次请求/响应
+type RecordedExchange struct {
+	Method          string
+	Path            string
+	Query           url.Values
+	Headers         http.Header
+	Body            []byte
+	StatusCode      int
+	ResponseHeaders http.Header
+	ResponseBody    []byte
+}
+
+// Recorder 反向代理，转发请求到目标服务并记录每一次请求/响应
+type Recorder struct {
+	target    *url.URL
+	proxy     *httputil.ReverseProxy
+	mu        sync.Mutex
+	exchanges []RecordedExchange
+}
+
+// NewRecorder 创建转发到 target 的录制代理
+func NewRecorder(target string) (*Recorder, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target url: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid target url '%s': scheme and host are required", target)
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = u.Host
+		// 不接受压缩，保证记录的响应体是明文
+		req.Header.Del("Accept-Encoding")
+	}
+	return &Recorder{target: u, proxy: proxy}, nil
+}
+
+// ServeHTTP 转发请求并记录请求和响应
+func (rec *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body []byte
+	if r.Body != nil {
+		body, _ = io.ReadAll(r.Body)
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	exchange := RecordedExchange{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Query:   r.URL.Query(),
+		Headers: r.Header.Clone(),
+		Body:    body,
+	}
+
+	cw := &capturingWriter{ResponseWriter: w, status: http.StatusOK}
+	rec.proxy.ServeHTTP(cw, r)
+
+	exchange.StatusCode = cw.status
+	exchange.ResponseHeaders = w.Header().Clone()
+	exchange.ResponseBody = cw.body.Bytes()
+
+	rec.mu.Lock()
+	rec.exchanges = append(rec.exchanges, exchange)
+	rec.mu.Unlock()
+}
+
+// Exchanges 返回按到达顺序记录的请求/响应副本
+func (rec *Recorder) Exchanges() []RecordedExchange {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]RecordedExchange(nil), rec.exchanges...)
+}
+
+// Suite 将记录的请求/响应转换为测试套件，base_url 为代理的目标地址
+func (rec *Recorder) Suite(name string) *TestSuite {
+	return RecordedSuite(name, strings.TrimSuffix(rec.target.String(), "/"), rec.Exchanges())
+}
+
+// capturingWriter 记录状态码和响应体的 ResponseWriter
+type capturingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *capturingWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *capturingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush 支持流式响应
+func (w *capturingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// recordSkipHeaders 不写入用例的请求头（逐跳头、客户端自动添加的头）
+var recordSkipHeaders = map[string]bool{
+	"Accept":                    true,
+	"Accept-Encoding":           true,
+	"Accept-Language":           true,
+	"Cache-Control":             true,
+	"Connection":                true,
+	"Content-Length":            true,
+	"Host":                      true,
+	"Keep-Alive":                true,
+	"Origin":                    true,
+	"Postman-Token":             true,
+	"Pragma":                    true,
+	"Referer":                   true,
+	"Te":                        true,
+	"Trailer":                   true,
+	"Transfer-Encoding":         true,
+	"Upgrade":                   true,
+	"Upgrade-Insecure-Requests": true,
+	"User-Agent":                true,
+	"X-Forwarded-For":           true,
+	"X-Forwarded-Host":          true,
+	"X-Forwarded-Proto":         true,
+}
+
+// recordIDKey 响应中可能在后续请求中使用的字段名：id、userId、user_id、uuid、token、access_token 等
+var recordIDKey = regexp.MustCompile(`(?i)(id|uuid|token|key)$`)
+
+// recordPathKey 可以用点号路径表示的字段名
+var recordPathKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// recordIndex 响应体路径中的数组下标
+var recordIndex = regexp.MustCompile(`\[\d+\]`)
+
+// recordCandidate 响应中出现的 id 值
+type recordCandidate struct {
+	testcase int    // 返回该值的用例下标
+	path     string // 响应体路径，例如 data.id
+	key      string // 字段名
+	variable string // 被后续请求使用后分配的变量名
+}
+
+// RecordedSuite 将记录的请求/响应转换为测试套件
+//
+// 每个请求生成一个用例，expect.status_code 取实际状态码，response_body 取 JSON 响应顶层的标量字段
+// （排除 id 类字段和时间戳）。响应中 id/uuid/token 类字段的值如果在后续请求的路径、查询参数、请求头
+// 或 JSON/表单请求体中原样出现，会在返回该值的用例上生成 save，并在后续请求中替换为 {{变量}} 引用。
+// 其他请求体（XML、multipart、文本等）以 body_type: raw 原样回放。
+// 小于 10 的整数不作为 id 处理，避免误替换分页等参数
+func RecordedSuite(name, baseURL string, exchanges []RecordedExchange) *TestSuite {
+	suite := &TestSuite{
+		Suite:     SuiteConfig{Name: name, BaseURL: baseURL},
+		Scenarios: []Scenario{{Name: name}},
+	}
+	if suite.Suite.Name == "" {
+		suite.Suite.Name = "Recorded"
+		suite.Scenarios[0].Name = "Recorded"
+	}
+
+	cases := make([]TestCase, 0, len(exchanges))
+	candidates := make(map[string]*recordCandidate)
+	variables := make(map[string]bool)
+	names := make(map[string]int)
+
+	for i, ex := range exchanges {
+		tc := TestCase{
+			Name: ex.Method + " " + ex.Path,
+			Request: RequestConfig{
+				Method: ex.Method,
+				Path:   ex.Path,
+			},
+			Expect: ExpectConfig{StatusCode: ex.StatusCode},
+		}
+		if n := names[tc.Name]; n > 0 {
+			tc.Name = fmt.Sprintf("%s #%d", tc.Name, n+1)
+		}
+		names[ex.Method+" "+ex.Path]++
+
+		// use 返回值对应的变量引用，并在返回该值的用例上生成 save；depends_on 指向最近的一个来源用例
+		dependency := -1
+		use := func(value string) (string, bool) {
+			c, ok := candidates[value]
+			if !ok {
+				return "", false
+			}
+			if c.variable == "" {
+				c.variable = recordVariableName(c, exchanges[c.testcase].Path, variables)
+				if cases[c.testcase].Save == nil {
+					cases[c.testcase].Save = make(map[string]string)
+				}
+				cases[c.testcase].Save[c.variable] = c.path
+			}
+			if c.testcase > dependency {
+				dependency = c.testcase
+				tc.DependsOn = cases[c.testcase].Name
+			}
+			return "{{" + c.variable + "}}", true
+		}
+
+		segments := strings.Split(ex.Path, "/")
+		for j, segment := range segments {
+			if ref, ok := use(segment); ok {
+				segments[j] = ref
+			}
+		}
+		tc.Request.Path = strings.Join(segments, "/")
+
+		for _, key := range sortedQueryKeys(ex.Query) {
+			if tc.Request.Query == nil {
+				tc.Request.Query = make(map[string]string)
+			}
+			value := ex.Query[key][0]
+			if ref, ok := use(value); ok {
+				value = ref
+			}
+			tc.Request.Query[key] = value
+		}
+
+		for _, key := range sortedQueryKeys(url.Values(ex.Headers)) {
+			if recordSkipHeaders[http.CanonicalHeaderKey(key)] || strings.HasPrefix(key, "Sec-") {
+				continue
+			}
+			if tc.Request.Headers == nil {
+				tc.Request.Headers = make(map[string]string)
+			}
+			value := ex.Headers[key][0]
+			scheme, credential, hasScheme := strings.Cut(value, " ")
+			if ref, ok := use(value); ok {
+				value = ref
+			} else if hasScheme {
+				if ref, ok := use(credential); ok {
+					value = scheme + " " + ref
+				}
+			}
+			tc.Request.Headers[key] = value
+		}
+
+		if len(ex.Body) > 0 {
+			tc.Request = recordRequestBody(tc.Request, ex, use)
+		}
+
+		if len(ex.ResponseBody) > 0 {
+			if data, err := decodeJSON(ex.ResponseBody); err == nil {
+				tc.Expect.ResponseBody = recordResponseBody(data)
+				recordCandidates(data, "", "", i, candidates)
+			}
+		}
+
+		cases = append(cases, tc)
+	}
+
+	suite.Scenarios[0].TestCases = cases
+	return suite
+}
+
+// recordRequestBody 写入记录的请求体：JSON 对象和表单写入 body（id 值替换为变量引用），
+// 其他请求体（JSON 数组、XML、multipart、文本或二进制）作为 raw 原样回放，Content-Type 取自记录的请求头
+func recordRequestBody(cfg RequestConfig, ex RecordedExchange, use func(string) (string, bool)) RequestConfig {
+	mediaType, _, _ := mime.ParseMediaType(ex.Headers.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" {
+		if form, err := url.ParseQuery(string(ex.Body)); err == nil {
+			body := make(map[string]any, len(form))
+			for key, values := range form {
+				body[key] = mockFormValue(values)
+			}
+			cfg.BodyType = BodyTypeForm
+			cfg.Body = recordReplaceBody(body, use).(map[string]any)
+			return cfg
+		}
+	}
+	if data, err := decodeJSON(ex.Body); err == nil {
+		if body, ok := data.(map[string]any); ok {
+			cfg.Body = recordReplaceBody(body, use).(map[string]any)
+			return cfg
+		}
+	}
+	cfg.BodyType = BodyTypeRaw
+	cfg.Raw = string(ex.Body)
+	return cfg
+}
+
+// recordReplaceBody 将请求体中出现的 id 值替换为变量引用
+func recordReplaceBody(v any, use func(string) (string, bool)) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for _, k := range sortedKeys(val) {
+			val[k] = recordReplaceBody(val[k], use)
+		}
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = recordReplaceBody(item, use)
+		}
+		return val
+	}
+	if key, ok := recordValueKey(v); ok {
+		if ref, ok := use(key); ok {
+			return ref
+		}
+	}
+	return v
+}
+
+// recordCandidates 收集响应中 id 类字段的值，同一个值只记录第一次出现的位置
+func recordCandidates(v any, path, key string, testcase int, candidates map[string]*recordCandidate) {
+	switch val := v.(type) {
+	case map[string]any:
+		for _, k := range sortedKeys(val) {
+			if !recordPathKey.MatchString(k) {
+				continue
+			}
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			recordCandidates(val[k], child, k, testcase, candidates)
+		}
+		return
+	case []any:
+		for i, item := range val {
+			recordCandidates(item, fmt.Sprintf("%s[%d]", path, i), key, testcase, candidates)
+		}
+		return
+	}
+
+	if path == "" || !recordIDKey.MatchString(key) {
+		return
+	}
+	value, ok := recordValueKey(v)
+	if !ok {
+		return
+	}
+	if _, exists := candidates[value]; !exists {
+		candidates[value] = &recordCandidate{testcase: testcase, path: path, key: key}
+	}
+}
+
+// recordValueKey 返回可以作为 id 的值的字符串形式：长度不小于 3 的字符串或不小于 10 的整数
+func recordValueKey(v any) (string, bool) {
+	switch val := v.(type) {
+	case string:
+		return val, len(val) >= 3
+	case int64:
+		return fmt.Sprint(val), val >= 10
+	case uint64:
+		return fmt.Sprint(val), val >= 10
+	}
+	return "", false
+}
+
+// recordResponseBody 返回 JSON 对象顶层的标量字段，排除 id 类字段和时间戳
+func recordResponseBody(data any) map[string]any {
+	obj, ok := data.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	expected := make(map[string]any)
+	for key, value := range obj {
+		if recordIDKey.MatchString(key) {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			if _, err := time.Parse(time.RFC3339, v); err == nil {
+				continue
+			}
+			expected[key] = v
+		case bool, int64, uint64, float64, nil:
+			expected[key] = v
+		}
+	}
+	if len(expected) == 0 {
+		return nil
+	}
+	return expected
+}
+
+// recordVariableName 生成变量名：字段名为 id 时使用所属对象名或资源名，例如 data.user.id -> user_id，POST /orders -> order_id
+func recordVariableName(c *recordCandidate, requestPath string, used map[string]bool) string {
+	name := recordSnakeCase(c.key)
+	if name == "id" {
+		parts := strings.Split(recordIndex.ReplaceAllString(c.path, ""), ".")
+		parent := resourceName(requestPath)
+		if len(parts) >= 2 && parts[len(parts)-2] != "data" {
+			parent = recordSnakeCase(parts[len(parts)-2])
+		}
+		name = parent + "_id"
+	}
+
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// recordSnakeCase 将 userId、user-id 转换为 user_id
+func recordSnakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '-':
+			b.WriteRune('_')
+		case r >= 'A' && r <= 'Z':
+			if i > 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r + 'a' - 'A')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return strings.ReplaceAll(b.String(), "__", "_")
+}
+
+// sortedQueryKeys 返回排序后的查询参数名或请求头名
+func sortedQueryKeys(q url.Values) []string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,205 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/login":
+			io.WriteString(w, `{"access_token": "tok-abc123"}`)
+		case r.Method == "POST" && r.URL.Path == "/users":
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"code": 0, "message": "ok", "data": {"id": 12345, "name": "John"}, "created_at": "2024-01-01T00:00:00Z"}`)
+		case r.URL.Path == "/users/12345" && r.Header.Get("Authorization") == "Bearer tok-abc123":
+			io.WriteString(w, `{"code": 0, "data": {"id": 12345, "name": "John"}}`)
+		case r.URL.Path == "/orders":
+			body, _ := io.ReadAll(r.Body)
+			if !strings.Contains(strings.ReplaceAll(string(body), " ", ""), `"user_id":12345`) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			io.WriteString(w, `{"code": 0}`)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer backend.Close()
+
+	recorder, err := NewRecorder(backend.URL)
+	if err != nil {
+		t.Fatalf("NewRecorder failed: %v", err)
+	}
+	proxy := httptest.NewServer(recorder)
+	defer proxy.Close()
+
+	send := func(method, path, auth, body string) {
+		req, _ := http.NewRequest(method, proxy.URL+path, strings.NewReader(body))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		resp.Body.Close()
+	}
+	send("POST", "/login", "", `{"username": "admin"}`)
+	send("POST", "/users", "", `{"name": "John"}`)
+	send("GET", "/users/12345?page=1", "Bearer tok-abc123", "")
+	send("GET", "/users/12345", "Bearer tok-abc123", "")
+	send("POST", "/orders", "", `{"user_id": 12345, "page": 1}`)
+
+	suite := recorder.Suite("Recorded Flow")
+	if suite.Suite.BaseURL != backend.URL {
+		t.Errorf("Expected base_url %s, got %s", backend.URL, suite.Suite.BaseURL)
+	}
+	cases := suite.Scenarios[0].TestCases
+	if len(cases) != 5 {
+		t.Fatalf("Expected 5 test cases, got %d", len(cases))
+	}
+
+	if !reflect.DeepEqual(cases[0].Save, map[string]string{"access_token": "access_token"}) {
+		t.Errorf("Unexpected login save: %v", cases[0].Save)
+	}
+	create := cases[1]
+	if !reflect.DeepEqual(create.Save, map[string]string{"user_id": "data.id"}) {
+		t.Errorf("Unexpected create save: %v", create.Save)
+	}
+	if !reflect.DeepEqual(create.Expect.ResponseBody, map[string]any{"code": int64(0), "message": "ok"}) {
+		t.Errorf("Unexpected response_body: %v", create.Expect.ResponseBody)
+	}
+
+	get := cases[2]
+	if get.Request.Path != "/users/{{user_id}}" || get.Request.Headers["Authorization"] != "Bearer {{access_token}}" {
+		t.Errorf("Expected ids to be replaced, got %+v", get.Request)
+	}
+	if get.Request.Query["page"] != "1" || get.DependsOn != "POST /users" {
+		t.Errorf("Unexpected query or dependency: %+v", get)
+	}
+	if _, ok := get.Request.Headers["User-Agent"]; ok {
+		t.Error("User-Agent should not be recorded")
+	}
+	if cases[3].Name != "GET /users/12345 #2" {
+		t.Errorf("Expected duplicate name to be numbered, got %q", cases[3].Name)
+	}
+	if body := cases[4].Request.Body; body["user_id"] != "{{user_id}}" || body["page"] != int64(1) {
+		t.Errorf("Unexpected order body: %v", body)
+	}
+
+	// 回放生成的套件
+	var buf bytes.Buffer
+	if err := WriteSuite(&buf, suite); err != nil {
+		t.Fatalf("WriteSuite failed: %v", err)
+	}
+	configPath := filepath.Join(t.TempDir(), "recorded.yaml")
+	os.WriteFile(configPath, buf.Bytes(), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("Failed to create TestRunner: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("TestRunner.Run failed: %v", err)
+	}
+	for _, result := range runner.GetResults() {
+		if !result.Passed {
+			t.Errorf("Replayed case %s failed: %s", result.Name, result.Error)
+		}
+	}
+}
+
+func TestRecorderNonJSONBodies(t *testing.T) {
+	bodies := map[string]struct{ contentType, body string }{
+		"/form":   {"application/x-www-form-urlencoded", "name=John&tag=a&tag=b"},
+		"/xml":    {"application/xml", `<user><name>John</name></user>`},
+		"/batch":  {"application/json", `[{"name": "John"}]`},
+		"/upload": {"multipart/form-data; boundary=XyZ", "--XyZ\r\nContent-Disposition: form-data; name=\"note\"\r\n\r\nhi\r\n--XyZ--\r\n"},
+		"/binary": {"application/octet-stream", "\x89PNG\x00\xff"},
+	}
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		want := bodies[r.URL.Path]
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Content-Type") != want.contentType {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		if r.URL.Path == "/form" {
+			form, _ := url.ParseQuery(string(body))
+			if form.Get("name") != "John" || !reflect.DeepEqual(form["tag"], []string{"a", "b"}) {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+			return
+		}
+		if string(body) != want.body {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer backend.Close()
+
+	recorder, err := NewRecorder(backend.URL)
+	if err != nil {
+		t.Fatalf("NewRecorder failed: %v", err)
+	}
+	proxy := httptest.NewServer(recorder)
+	defer proxy.Close()
+
+	for _, path := range []string{"/form", "/xml", "/batch", "/upload", "/binary"} {
+		resp, err := http.Post(proxy.URL+path, bodies[path].contentType, strings.NewReader(bodies[path].body))
+		if err != nil {
+			t.Fatalf("POST %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST %s through proxy returned %d", path, resp.StatusCode)
+		}
+	}
+
+	suite := recorder.Suite("Bodies")
+	cases := suite.Scenarios[0].TestCases
+	if form := cases[0].Request; form.BodyType != BodyTypeForm || form.Body["name"] != "John" {
+		t.Errorf("Expected form body, got %+v", form)
+	}
+	for _, tc := range cases[1:] {
+		if tc.Request.BodyType != BodyTypeRaw || tc.Request.Raw != bodies[tc.Request.Path].body {
+			t.Errorf("Expected raw body for %s, got %+v", tc.Name, tc.Request)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSuite(&buf, suite); err != nil {
+		t.Fatalf("WriteSuite failed: %v", err)
+	}
+	configPath := filepath.Join(t.TempDir(), "bodies.yaml")
+	os.WriteFile(configPath, buf.Bytes(), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("Failed to create TestRunner: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("TestRunner.Run failed: %v", err)
+	}
+	for _, result := range runner.GetResults() {
+		if !result.Passed {
+			t.Errorf("Replayed case %s failed: %s", result.Name, result.Error)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package apitest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// 响应的特殊断言路径，可以用于 assertions 和 save
+const (
+	PathBodyText   = "body_text"   // 响应体文本
+	PathBodySize   = "body_size"   // 响应体字节数
+	PathBodySHA256 = "body_sha256" // 响应体 SHA-256 的十六进制值
+	XPathPrefix    = "xpath:"      // 以 xpath: 开头的路径在 XML 响应上求值，语法见 xpath
+	HeaderPrefix   = "header."     // header.<Name> 响应头的值（名称不区分大小写）
+	CookiePrefix   = "cookie."     // cookie.<name> 响应设置的 Cookie 的值
+)
+
+// response 按 Content-Type 解析后的响应体，断言、schema 校验和保存变量都基于它取值
+type response struct {
+	raw       []byte
+	header    http.Header
+	mediaType string // 不含参数的 Content-Type
+	data      any    // JSON 值，非 JSON 响应为 nil
+	xmlDoc    *xmlNode
+	xmlErr    error
+}
+
+// parseResponse 按 Content-Type 解析响应体
+// JSON 类型必须是合法的 JSON；未声明类型或 text/* 时尝试按 JSON 解析（兼容以文本类型返回 JSON 的服务），
+// 失败则作为文本；其他类型只保留原始内容
+func parseResponse(header http.Header, body []byte) (*response, error) {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	resp := &response{raw: body, header: header, mediaType: mediaType}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return resp, nil
+	}
+	switch {
+	case isJSONMediaType(mediaType):
+		data, err := decodeJSON(body)
+		if err != nil {
+			return nil, err
+		}
+		resp.data = data
+	case mediaType == "", strings.HasPrefix(mediaType, "text/"):
+		if data, err := decodeJSON(body); err == nil {
+			resp.data = data
+		}
+	}
+	return resp, nil
+}
+
+// isJSON 响应体是否按 JSON 解析
+func (resp *response) isJSON() bool {
+	return resp.data != nil || string(bytes.TrimSpace(resp.raw)) == "null"
+}
+
+// isText 响应体是否为文本
+func (resp *response) isText() bool {
+	mt := resp.mediaType
+	switch {
+	case strings.HasPrefix(mt, "text/"), isJSONMediaType(mt), isXMLMediaType(mt),
+		mt == "application/javascript", mt == "application/x-www-form-urlencoded":
+		return true
+	case mt == "":
+		return utf8.Valid(resp.raw)
+	}
+	return false
+}
+
+// body 用于报告的响应体：JSON 值、文本或 nil（二进制）
+func (resp *response) body() any {
+	switch {
+	case resp.isJSON():
+		return resp.data
+	case len(resp.raw) > 0 && resp.isText():
+		return string(resp.raw)
+	}
+	return nil
+}
+
+// xml 解析 XML 响应体，结果会被缓存
+func (resp *response) xml() (*xmlNode, error) {
+	if resp.xmlDoc == nil && resp.xmlErr == nil {
+		resp.xmlDoc, resp.xmlErr = parseXMLDocument(resp.raw)
+		if resp.xmlErr != nil {
+			resp.xmlErr = fmt.Errorf("response is not valid XML: %w", resp.xmlErr)
+		}
+	}
+	return resp.xmlDoc, resp.xmlErr
+}
+
+// lookup 求值断言路径：特殊路径、header.、cookie.、xpath: 或 JSONPath
+func (resp *response) lookup(path string) (any, bool, error) {
+	// 没有同名的响应头或 Cookie 时按 JSONPath 读取响应体，兼容响应体中名为 header、cookie 的字段
+	if name, ok := strings.CutPrefix(path, HeaderPrefix); ok {
+		if value, found := resp.headerValue(name); found {
+			return value, true, nil
+		}
+		return resolvePath(path, resp.data)
+	}
+	if name, ok := strings.CutPrefix(path, CookiePrefix); ok {
+		if value, found := resp.cookieValue(name); found {
+			return value, true, nil
+		}
+		return resolvePath(path, resp.data)
+	}
+	switch {
+	case path == PathBodyText:
+		return string(resp.raw), true, nil
+	case path == PathBodySize:
+		return int64(len(resp.raw)), true, nil
+	case path == PathBodySHA256:
+		sum := sha256.Sum256(resp.raw)
+		return hex.EncodeToString(sum[:]), true, nil
+	case strings.HasPrefix(path, XPathPrefix):
+		x, err := compileXPath(strings.TrimPrefix(path, XPathPrefix))
+		if err != nil {
+			return nil, false, err
+		}
+		doc, err := resp.xml()
+		if err != nil {
+			return nil, false, err
+		}
+		return x.evaluate(doc)
+	}
+	return resolvePath(path, resp.data)
+}
+
+// headerValue 返回响应头的值，同名多个值时返回 []any
+func (resp *response) headerValue(name string) (any, bool) {
+	values := resp.header.Values(name)
+	switch len(values) {
+	case 0:
+		return nil, false
+	case 1:
+		return values[0], true
+	}
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out, true
+}
+
+// cookieValue 返回 Set-Cookie 中同名 Cookie 的值，多次设置时取最后一个
+func (resp *response) cookieValue(name string) (any, bool) {
+	cookies := (&http.Response{Header: resp.header}).Cookies()
+	for i := len(cookies) - 1; i >= 0; i-- {
+		if cookies[i].Name == name {
+			return cookies[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// lookupValue 在 *response 或已解析的 JSON 值上求值路径
+func lookupValue(path string, data any) (any, bool, error) {
+	if resp, ok := data.(*response); ok {
+		return resp.lookup(path)
+	}
+	return resolvePath(path, data)
+}
+
+// isXMLMediaType 是否为 XML 媒体类型
+func isXMLMediaType(mediaType string) bool {
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
@@ -0,0 +1,303 @@
+package apitest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTestRunnerNonJSONResponses(t *testing.T) {
+	binary := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}
+	sum := sha256.Sum256(binary)
+
+	configPath := filepath.Join(t.TempDir(), "formats.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "Formats"
+variables:
+  png_sha256: "`+hex.EncodeToString(sum[:])+`"
+scenarios:
+  - name: "Responses"
+    testcases:
+      - name: "Top-level Array"
+        request: { method: GET, path: /users }
+        expect:
+          status_code: 200
+          assertions:
+            - { path: "$", operator: "length", value: 2 }
+            - { path: "$[1].name", operator: "equals", value: "Bob" }
+        save: { first_user: "$[0].name" }
+      - name: "Plain Text"
+        request: { method: GET, path: /health }
+        expect:
+          assertions:
+            - { path: "body_text", operator: "equals", value: "OK" }
+      - name: "HTML"
+        request: { method: GET, path: /page }
+        expect:
+          assertions:
+            - { path: "body_text", operator: "contains", value: "<h1>Hello {{first_user}}</h1>" }
+      - name: "CSV"
+        request: { method: GET, path: /export.csv }
+        expect:
+          assertions:
+            - { path: "body_text", operator: "startsWith", value: "id,name\n" }
+            - { path: "body_text", operator: "matches", value: "(?m)^2,Bob$" }
+      - name: "XML"
+        request: { method: GET, path: /order.xml }
+        expect:
+          assertions:
+            - { path: "xpath:/order/@id", operator: "equals", value: "7" }
+            - { path: "xpath:count(/order/item)", operator: "equals", value: 2 }
+            - { path: "xpath:number(/order/total)", operator: "greaterThan", value: 10 }
+            - { path: "xpath://item/@sku", operator: "contains", value: "B-2" }
+        save: { order_id: "xpath:/order/@id" }
+      - name: "Binary Download"
+        request: { method: GET, path: /logo.png }
+        expect:
+          assertions:
+            - { path: "body_size", operator: "equals", value: 6 }
+            - { path: "body_sha256", operator: "equals", value: "{{png_sha256}}" }
+      - name: "Empty Body"
+        request: { method: DELETE, path: "/orders/{{order_id}}" }
+        expect:
+          status_code: 204
+          assertions:
+            - { path: "body_size", operator: "equals", value: 0 }
+      - name: "XPath On JSON"
+        request: { method: GET, path: /users }
+        expect:
+          assertions:
+            - { path: "xpath:/users", operator: "exists" }
+      - name: "Broken JSON"
+        request: { method: GET, path: /broken }
+        expect: { status_code: 200 }
+`), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("NewTestRunner failed: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	runner.SetHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reply := func(contentType, body string) {
+			w.Header().Set("Content-Type", contentType)
+			io.WriteString(w, body)
+		}
+		switch r.URL.Path {
+		case "/users":
+			reply("application/json", `[{"name":"Alice"},{"name":"Bob"}]`)
+		case "/health":
+			reply("text/plain; charset=utf-8", "OK")
+		case "/page":
+			reply("text/html", "<html><body><h1>Hello Alice</h1></body></html>")
+		case "/export.csv":
+			reply("text/csv", "id,name\n1,Alice\n2,Bob\n")
+		case "/order.xml":
+			reply("application/xml", `<order id="7"><total>12.5</total><item sku="A-1"/><item sku="B-2"/></order>`)
+		case "/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(binary)
+		case "/orders/7":
+			w.WriteHeader(http.StatusNoContent)
+		case "/broken":
+			reply("application/json", "{not json")
+		}
+	}))
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	results := runner.GetResults()
+	for _, result := range results[:7] {
+		if !result.Passed {
+			t.Errorf("Expected %s to pass, got %s", result.Name, result.Error)
+		}
+	}
+	if body := results[1].Response.Body; body != "OK" {
+		t.Errorf("Expected text body in result, got %#v", body)
+	}
+	if body := results[5].Response.Body; body != nil {
+		t.Errorf("Expected no body for binary response, got %#v", body)
+	}
+	if results[7].Passed || !strings.Contains(results[7].Error, "response is not valid XML") {
+		t.Errorf("Expected xpath on JSON to fail, got %+v", results[7])
+	}
+	if results[8].Passed || !strings.Contains(results[8].Error, "parse response failed") {
+		t.Errorf("Expected invalid JSON to fail, got %+v", results[8])
+	}
+}
+
+func TestTestRunnerJSONServedAsText(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "json_as_text.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "JSON As Text"
+scenarios:
+  - name: "Legacy Server"
+    testcases:
+      - name: "Plain Text JSON"
+        request: { method: GET, path: /users/1 }
+        expect:
+          status_code: 200
+          response_body: { name: "Alice" }
+          assertions:
+            - { path: "$.roles[0]", operator: "equals", value: "admin" }
+        save: { user_name: "name" }
+      - name: "HTML JSON"
+        request: { method: GET, path: "/greet/{{user_name}}" }
+        expect:
+          assertions:
+            - { path: "message", operator: "equals", value: "Hello Alice" }
+`), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("NewTestRunner failed: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	runner.SetHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/1":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			io.WriteString(w, `{"name": "Alice", "roles": ["admin"]}`)
+		case "/greet/Alice":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, `{"message": "Hello Alice"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, result := range runner.GetResults() {
+		if !result.Passed {
+			t.Errorf("Expected %s to pass, got %s", result.Name, result.Error)
+		}
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		want        any
+	}{
+		{"application/json", `{"a": 1}`, map[string]any{"a": int64(1)}},
+		{"application/problem+json", `[1]`, []any{int64(1)}},
+		{"", `{"a": 1}`, map[string]any{"a": int64(1)}},
+		{"", "plain", "plain"},
+		{"text/plain", `{"a": 1}`, map[string]any{"a": int64(1)}},
+		{"text/html", `[1]`, []any{int64(1)}},
+		{"text/plain", "OK", "OK"},
+		{"application/xml", "<a/>", "<a/>"},
+		{"application/octet-stream", "\x00\x01", nil},
+		{"application/json", "", nil},
+	}
+	for _, tt := range tests {
+		resp, err := parseResponse(http.Header{"Content-Type": {tt.contentType}}, []byte(tt.body))
+		if err != nil {
+			t.Errorf("parseResponse(%q, %q) returned error: %v", tt.contentType, tt.body, err)
+			continue
+		}
+		if got := resp.body(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseResponse(%q, %q).body() = %#v, want %#v", tt.contentType, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestTestRunnerHeaderAndCookieAssertions(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "headers.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "Headers"
+scenarios:
+  - name: "Users"
+    testcases:
+      - name: "Create User"
+        request: { method: POST, path: /users, body: { name: "Alice" } }
+        expect:
+          status_code: 201
+          assertions:
+            - { path: "header.Content-Type", operator: "contains", value: "json" }
+            - { path: "header.location", operator: "matches", value: "^/users/\\d+$" }
+            - { path: "header.X-Trace", operator: "length", value: 2 }
+            - { path: "header.X-Missing", operator: "notExists" }
+            - { path: "cookie.session", operator: "exists" }
+            - { path: "cookie.theme", operator: "equals", value: "dark" }
+            - { path: "header.id", operator: "equals", value: 9 }
+            - { path: "$.header.id", operator: "equals", value: 9 }
+            - { path: "cookie.flavor", operator: "equals", value: "oat" }
+        save:
+          user_id: "header.Location | /users/(\\d+)$"
+          user_path: "header.Location"
+          session: "cookie.session"
+          missing: "header.Location | ^/orders/"
+      - name: "Get User"
+        request:
+          method: GET
+          path: "{{user_path}}"
+          headers: { Cookie: "session={{session}}" }
+        expect:
+          status_code: 200
+          assertions:
+            - { path: "id", operator: "equals", value: "{{user_id}}" }
+      - name: "Invalid Save Pattern"
+        request: { method: POST, path: /users }
+        expect: { status_code: 201 }
+        save: { bad: "header.Location | (" }
+`), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("NewTestRunner failed: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	runner.SetHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if r.Method == http.MethodPost {
+			w.Header().Set("Location", "/users/42")
+			w.Header().Add("X-Trace", "a")
+			w.Header().Add("X-Trace", "b")
+			http.SetCookie(w, &http.Cookie{Name: "theme", Value: "light"})
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t", HttpOnly: true})
+			http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"header": {"id": 9}, "cookie": {"flavor": "oat"}}`)
+			return
+		}
+		if c, err := r.Cookie("session"); err != nil || c.Value != "s3cr3t" || r.URL.Path != "/users/42" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, `{"id": "42"}`)
+	}))
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	results := runner.GetResults()
+	for _, result := range results[:2] {
+		if !result.Passed {
+			t.Errorf("Expected %s to pass, got %s", result.Name, result.Error)
+		}
+	}
+	saved := results[0].Saved
+	if saved["user_id"] != "42" || saved["user_path"] != "/users/42" || saved["session"] != "s3cr3t" || saved["missing"] != nil {
+		t.Errorf("Unexpected saved variables: %v", saved)
+	}
+	if results[2].Passed || !strings.Contains(results[2].Error, "save failed: invalid regular expression") {
+		t.Errorf("Expected invalid save pattern to fail, got %+v", results[2])
+	}
+}
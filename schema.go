@@ -0,0 +1,608 @@
+package apitest
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaViolation JSON Schema 校验错误
+type SchemaViolation struct {
+	Pointer string `json:"pointer"` // 实例中出错位置的 JSON Pointer，根节点为空字符串
+	Message string `json:"message"`
+}
+
+// String 返回 "pointer: message" 形式的描述
+func (v SchemaViolation) String() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return pointer + ": " + v.Message
+}
+
+// ValidateSchema 使用 JSON Schema（draft 2020-12 的子集）校验 instance，返回所有违反项
+//
+// 支持 type、enum、const、数值/字符串/数组/对象约束、properties、patternProperties、
+// additionalProperties、prefixItems、items、contains、allOf/anyOf/oneOf/not、if/then/else、
+// dependentRequired/dependentSchemas、以文档内 $ref（#/$defs/...）引用、OpenAPI 3.0 的 nullable，以及常用 format
+// （date-time、date、time、email、uuid、uri、ipv4、ipv6）。
+// 不支持的关键字（见 unsupportedSchemaKeywords）和指向文档外的 $ref 记为违反项，避免 schema 被静默地当作通过
+func ValidateSchema(schema, instance any) []SchemaViolation {
+	v := &schemaValidator{root: schema, patterns: make(map[string]*regexp.Regexp)}
+	v.validate(schema, instance, "")
+	return v.violations
+}
+
+// schemaValidator 保存校验过程中的状态
+type schemaValidator struct {
+	root       any // 用于解析 $ref 的根文档
+	patterns   map[string]*regexp.Regexp
+	violations []SchemaViolation
+	depth      int
+}
+
+// unsupportedSchemaKeywords 无法正确实现的 draft 2020-12 关键字
+var unsupportedSchemaKeywords = []string{"unevaluatedProperties", "unevaluatedItems", "$anchor", "$dynamicAnchor", "$dynamicRef", "$recursiveRef"}
+
+// maxSchemaDepth 防止循环 $ref 导致无限递归
+const maxSchemaDepth = 64
+
+func (v *schemaValidator) fail(pointer, format string, args ...any) {
+	v.violations = append(v.violations, SchemaViolation{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+// valid 在独立的子校验器上校验，不记录错误，用于 anyOf/oneOf/not/if/contains
+func (v *schemaValidator) valid(schema, instance any, pointer string) bool {
+	sub := &schemaValidator{root: v.root, patterns: v.patterns, depth: v.depth}
+	sub.validate(schema, instance, pointer)
+	return len(sub.violations) == 0
+}
+
+// validate 校验 instance，错误记录到 v.violations
+func (v *schemaValidator) validate(schema, instance any, pointer string) {
+	if v.depth > maxSchemaDepth {
+		v.fail(pointer, "schema nesting too deep (circular $ref?)")
+		return
+	}
+	v.depth++
+	defer func() { v.depth-- }()
+
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.fail(pointer, "value is not allowed")
+		}
+		return
+	case map[string]any:
+		v.validateObjectSchema(s, instance, pointer)
+	case nil:
+		return
+	default:
+		v.fail(pointer, "invalid schema of type %T", schema)
+	}
+}
+
+func (v *schemaValidator) validateObjectSchema(s map[string]any, instance any, pointer string) {
+	for _, keyword := range unsupportedSchemaKeywords {
+		if _, ok := s[keyword]; ok {
+			v.fail(pointer, "unsupported keyword '%s'", keyword)
+		}
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := resolveSchemaRef(v.root, ref)
+		if err != nil {
+			v.fail(pointer, "%v", err)
+		} else {
+			v.validate(target, instance, pointer)
+		}
+	}
+
+	// OpenAPI 3.0 的 nullable 扩展：允许 null
+	if nullable, _ := s["nullable"].(bool); nullable && instance == nil {
+		return
+	}
+
+	if t, ok := s["type"]; ok {
+		v.validateType(t, instance, pointer)
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		matched := false
+		for _, e := range enum {
+			if schemaEqual(e, instance) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(pointer, "value %s is not one of %s", schemaString(instance), schemaString(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !schemaEqual(c, instance) {
+		v.fail(pointer, "value %s should be %s", schemaString(instance), schemaString(c))
+	}
+
+	switch val := instance.(type) {
+	case string:
+		v.validateString(s, val, pointer)
+	case []any:
+		v.validateArray(s, val, pointer)
+	case map[string]any:
+		v.validateObject(s, val, pointer)
+	default:
+		if num, ok := toFloat64(instance); ok {
+			v.validateNumber(s, num, pointer)
+		}
+	}
+
+	v.validateComposition(s, instance, pointer)
+}
+
+// validateType 校验 type 关键字，支持字符串或字符串数组
+func (v *schemaValidator) validateType(t any, instance any, pointer string) {
+	var types []string
+	switch tv := t.(type) {
+	case string:
+		types = []string{tv}
+	case []any:
+		for _, item := range tv {
+			types = append(types, fmt.Sprint(item))
+		}
+	}
+
+	actual := jsonType(instance)
+	for _, typ := range types {
+		if typ == actual || (typ == "number" && actual == "integer") {
+			return
+		}
+	}
+	v.fail(pointer, "expected %s, got %s", strings.Join(types, " or "), actual)
+}
+
+func (v *schemaValidator) validateNumber(s map[string]any, num float64, pointer string) {
+	if m, ok := toFloat64(s["minimum"]); ok && num < m {
+		v.fail(pointer, "%v should be >= %v", num, m)
+	}
+	if m, ok := toFloat64(s["maximum"]); ok && num > m {
+		v.fail(pointer, "%v should be <= %v", num, m)
+	}
+	if m, ok := toFloat64(s["exclusiveMinimum"]); ok && num <= m {
+		v.fail(pointer, "%v should be > %v", num, m)
+	}
+	if m, ok := toFloat64(s["exclusiveMaximum"]); ok && num >= m {
+		v.fail(pointer, "%v should be < %v", num, m)
+	}
+	if m, ok := toFloat64(s["multipleOf"]); ok && m > 0 {
+		if q := num / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(pointer, "%v should be a multiple of %v", num, m)
+		}
+	}
+}
+
+func (v *schemaValidator) validateString(s map[string]any, str string, pointer string) {
+	length := int64(len([]rune(str)))
+	if m, ok := toInt64(s["minLength"]); ok && length < m {
+		v.fail(pointer, "length %d should be >= %d", length, m)
+	}
+	if m, ok := toInt64(s["maxLength"]); ok && length > m {
+		v.fail(pointer, "length %d should be <= %d", length, m)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := v.compile(pattern)
+		if err != nil {
+			v.fail(pointer, "invalid pattern %q: %v", pattern, err)
+		} else if !re.MatchString(str) {
+			v.fail(pointer, "%q does not match pattern %q", str, pattern)
+		}
+	}
+	if format, ok := s["format"].(string); ok && !validFormat(format, str) {
+		v.fail(pointer, "%q is not a valid %s", str, format)
+	}
+}
+
+func (v *schemaValidator) validateArray(s map[string]any, arr []any, pointer string) {
+	length := int64(len(arr))
+	if m, ok := toInt64(s["minItems"]); ok && length < m {
+		v.fail(pointer, "array should have at least %d items, got %d", m, length)
+	}
+	if m, ok := toInt64(s["maxItems"]); ok && length > m {
+		v.fail(pointer, "array should have at most %d items, got %d", m, length)
+	}
+	if unique, _ := s["uniqueItems"].(bool); unique {
+		for i := range arr {
+			for j := i + 1; j < len(arr); j++ {
+				if schemaEqual(arr[i], arr[j]) {
+					v.fail(pointer, "items %d and %d are equal", i, j)
+				}
+			}
+		}
+	}
+
+	prefix, _ := s["prefixItems"].([]any)
+	for i, item := range arr {
+		itemPointer := pointer + "/" + strconv.Itoa(i)
+		if i < len(prefix) {
+			v.validate(prefix[i], item, itemPointer)
+		} else if items, ok := s["items"]; ok {
+			v.validate(items, item, itemPointer)
+		}
+	}
+
+	if contains, ok := s["contains"]; ok {
+		count := int64(0)
+		for i, item := range arr {
+			if v.valid(contains, item, pointer+"/"+strconv.Itoa(i)) {
+				count++
+			}
+		}
+		minContains := int64(1)
+		if m, ok := toInt64(s["minContains"]); ok {
+			minContains = m
+		}
+		if count < minContains {
+			v.fail(pointer, "array should contain at least %d matching items, got %d", minContains, count)
+		}
+		if m, ok := toInt64(s["maxContains"]); ok && count > m {
+			v.fail(pointer, "array should contain at most %d matching items, got %d", m, count)
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(s map[string]any, obj map[string]any, pointer string) {
+	count := int64(len(obj))
+	if m, ok := toInt64(s["minProperties"]); ok && count < m {
+		v.fail(pointer, "object should have at least %d properties, got %d", m, count)
+	}
+	if m, ok := toInt64(s["maxProperties"]); ok && count > m {
+		v.fail(pointer, "object should have at most %d properties, got %d", m, count)
+	}
+
+	if required, ok := s["required"].([]any); ok {
+		for _, name := range required {
+			if _, ok := obj[fmt.Sprint(name)]; !ok {
+				v.fail(pointer, "missing required property '%v'", name)
+			}
+		}
+	}
+
+	if deps, ok := s["dependentRequired"].(map[string]any); ok {
+		for name, list := range deps {
+			if _, ok := obj[name]; !ok {
+				continue
+			}
+			required, _ := list.([]any)
+			for _, dep := range required {
+				if _, ok := obj[fmt.Sprint(dep)]; !ok {
+					v.fail(pointer, "property '%s' requires property '%v'", name, dep)
+				}
+			}
+		}
+	}
+	if deps, ok := s["dependentSchemas"].(map[string]any); ok {
+		for name, dep := range deps {
+			if _, ok := obj[name]; ok {
+				v.validate(dep, obj, pointer)
+			}
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]any)
+	patternProperties, _ := s["patternProperties"].(map[string]any)
+	additional, hasAdditional := s["additionalProperties"]
+	propertyNames, hasPropertyNames := s["propertyNames"]
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := obj[key]
+		propPointer := pointer + "/" + escapePointer(key)
+
+		if hasPropertyNames && !v.valid(propertyNames, key, propPointer) {
+			v.fail(propPointer, "property name '%s' is not allowed", key)
+		}
+
+		matched := false
+		if prop, ok := properties[key]; ok {
+			matched = true
+			v.validate(prop, value, propPointer)
+		}
+		for pattern, prop := range patternProperties {
+			re, err := v.compile(pattern)
+			if err != nil {
+				v.fail(pointer, "invalid pattern %q: %v", pattern, err)
+				continue
+			}
+			if re.MatchString(key) {
+				matched = true
+				v.validate(prop, value, propPointer)
+			}
+		}
+
+		if !matched && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				v.fail(propPointer, "additional property '%s' is not allowed", key)
+			} else {
+				v.validate(additional, value, propPointer)
+			}
+		}
+	}
+}
+
+// validateComposition 校验 allOf/anyOf/oneOf/not/if-then-else
+func (v *schemaValidator) validateComposition(s map[string]any, instance any, pointer string) {
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			v.validate(sub, instance, pointer)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if v.valid(sub, instance, pointer) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(pointer, "value does not match any schema in anyOf")
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]any); ok {
+		count := 0
+		for _, sub := range oneOf {
+			if v.valid(sub, instance, pointer) {
+				count++
+			}
+		}
+		if count != 1 {
+			v.fail(pointer, "value should match exactly one schema in oneOf, matched %d", count)
+		}
+	}
+	if not, ok := s["not"]; ok && v.valid(not, instance, pointer) {
+		v.fail(pointer, "value should not match schema in not")
+	}
+	if cond, ok := s["if"]; ok {
+		if v.valid(cond, instance, pointer) {
+			if then, ok := s["then"]; ok {
+				v.validate(then, instance, pointer)
+			}
+		} else if otherwise, ok := s["else"]; ok {
+			v.validate(otherwise, instance, pointer)
+		}
+	}
+}
+
+func (v *schemaValidator) compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := v.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	v.patterns[pattern] = re
+	return re, nil
+}
+
+// resolveSchemaRef 解析文档内的 $ref，例如 #/$defs/User 或 #/components/schemas/User
+func resolveSchemaRef(root any, ref string) (any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported $ref '%s': only references within the document are supported", ref)
+	}
+
+	current := root
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer == "" {
+		return current, nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = unescapePointer(token)
+		switch node := current.(type) {
+		case map[string]any:
+			next, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("unresolvable $ref '%s'", ref)
+			}
+			current = next
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("unresolvable $ref '%s'", ref)
+			}
+			current = node[i]
+		default:
+			return nil, fmt.Errorf("unresolvable $ref '%s'", ref)
+		}
+	}
+	return current, nil
+}
+
+// jsonType 返回值的 JSON Schema 类型名
+func jsonType(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case float64:
+		if val == math.Trunc(val) && !math.IsInf(val, 0) {
+			return "integer"
+		}
+		return "number"
+	case float32:
+		if float64(val) == math.Trunc(float64(val)) {
+			return "integer"
+		}
+		return "number"
+	}
+	if _, ok := toInt64(v); ok {
+		return "integer"
+	}
+	if _, ok := toFloat64(v); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// schemaEqual 按 JSON 语义比较两个值，数字按数值比较
+func schemaEqual(a, b any) bool {
+	if af, ok := toFloat64(a); ok {
+		bf, ok := toFloat64(b)
+		return ok && af == bf
+	}
+	switch av := a.(type) {
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !schemaEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			other, ok := bv[k]
+			if !ok || !schemaEqual(v, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// schemaString 将值格式化为简短的 JSON 风格字符串
+func schemaString(v any) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	if v == nil {
+		return "null"
+	}
+	return fmt.Sprint(v)
+}
+
+// validFormat 校验常用的 format，未知 format 视为通过
+func validFormat(format, s string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, s)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04:05Z07:00", s)
+		if err != nil {
+			_, err = time.Parse("15:04:05.999999999Z07:00", s)
+		}
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	case "uuid":
+		_, err := uuid.Parse(s)
+		return err == nil && len(s) == 36
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	case "ipv4":
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && strings.Contains(s, ".")
+	case "ipv6":
+		ip := net.ParseIP(s)
+		return ip != nil && strings.Contains(s, ":")
+	}
+	return true
+}
+
+// escapePointer 按 RFC 6901 转义 JSON Pointer 中的 ~ 和 /
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// unescapePointer 还原 JSON Pointer 中的转义
+func unescapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+}
+
+// loadSchema 加载 expect.schema：内联 schema 直接返回，字符串视为 JSON/YAML 文件路径（相对于套件文件所在目录）
+func (r *TestRunner) loadSchema(ref any) (any, error) {
+	path, ok := ref.(string)
+	if !ok {
+		return ref, nil // 内联 schema 已在加载套件时规范化
+	}
+
+	data, err := r.readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	var schema any
+	if err := yaml.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema %s: %w", path, err)
+	}
+	return normalizeYAML(schema), nil
+}
+
+// normalizeYAML 将 YAML 解码出的 map[any]any（例如以数字为键的映射）递归转换为 map[string]any
+// 返回新的 map 和切片，不修改传入的值，因此可以在共享的套件配置上调用
+func normalizeYAML(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(val))
+		for k, item := range val {
+			m[k] = normalizeYAML(item)
+		}
+		return m
+	case map[any]any:
+		m := make(map[string]any, len(val))
+		for k, item := range val {
+			m[fmt.Sprint(k)] = normalizeYAML(item)
+		}
+		return m
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = normalizeYAML(item)
+		}
+		return out
+	}
+	return v
+}
+
+// normalizeMap 对 map[string]any 调用 normalizeYAML，nil 保持为 nil
+func normalizeMap(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	return normalizeYAML(m).(map[string]any)
+}
@@ -0,0 +1,178 @@
+package apitest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	var schema any
+	schema, _ = decodeJSON([]byte(`{
+		"$defs": {
+			"item": {
+				"type": "object",
+				"required": ["id", "status"],
+				"properties": {
+					"id": {"type": "integer", "minimum": 1},
+					"status": {"enum": ["active", "inactive"]},
+					"email": {"type": "string", "format": "email"}
+				},
+				"additionalProperties": false
+			}
+		},
+		"type": "object",
+		"required": ["code", "data"],
+		"properties": {
+			"code": {"const": 0},
+			"data": {"type": "array", "items": {"$ref": "#/$defs/item"}, "minItems": 1}
+		}
+	}`))
+
+	valid, _ := decodeJSON([]byte(`{"code": 0, "data": [{"id": 1, "status": "active", "email": "a@example.com"}]}`))
+	if violations := ValidateSchema(schema, valid); len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+
+	invalid, _ := decodeJSON([]byte(`{"code": 1, "data": [{"id": 0, "status": "deleted", "email": "bad", "x/y": 1}, {"status": "active"}]}`))
+	violations := ValidateSchema(schema, invalid)
+
+	want := map[string]bool{
+		"/code":          false,
+		"/data/0/id":     false,
+		"/data/0/status": false,
+		"/data/0/email":  false,
+		"/data/0/x~1y":   false,
+		"/data/1":        false,
+	}
+	for _, v := range violations {
+		if _, ok := want[v.Pointer]; ok {
+			want[v.Pointer] = true
+		}
+	}
+	for pointer, found := range want {
+		if !found {
+			t.Errorf("Expected violation at %s, got %v", pointer, violations)
+		}
+	}
+}
+
+func TestValidateSchemaUnsupportedKeywords(t *testing.T) {
+	tests := []struct {
+		schema string
+		want   string
+	}{
+		{`{"type": "object", "unevaluatedProperties": false}`, "unsupported keyword 'unevaluatedProperties'"},
+		{`{"items": {"unevaluatedItems": false}}`, "unsupported keyword 'unevaluatedItems'"},
+		{`{"$defs": {"a": {"$anchor": "a"}}, "$ref": "#/$defs/a"}`, "unsupported keyword '$anchor'"},
+		{`{"$dynamicRef": "#meta"}`, "unsupported keyword '$dynamicRef'"},
+		{`{"$ref": "other.json#/$defs/a"}`, "only references within the document are supported"},
+	}
+	for _, tt := range tests {
+		schema, _ := decodeJSON([]byte(tt.schema))
+		instance, _ := decodeJSON([]byte(`[{}]`))
+		violations := ValidateSchema(schema, instance)
+		if len(violations) == 0 || !strings.Contains(violations[0].Message, tt.want) {
+			t.Errorf("ValidateSchema(%s) = %v, want %q", tt.schema, violations, tt.want)
+		}
+	}
+}
+
+func TestNormalizeYAML(t *testing.T) {
+	nested := map[any]any{1: "one"}
+	input := map[string]any{"codes": nested, "list": []any{nested}}
+	got := normalizeYAML(input).(map[string]any)
+
+	if codes, ok := got["codes"].(map[string]any); !ok || codes["1"] != "one" {
+		t.Errorf("Expected map[any]any to be converted, got %#v", got["codes"])
+	}
+	if _, ok := input["codes"].(map[any]any); !ok {
+		t.Errorf("normalizeYAML should not modify its input, got %#v", input["codes"])
+	}
+	if _, ok := input["list"].([]any)[0].(map[any]any); !ok {
+		t.Error("normalizeYAML should not modify slices in its input")
+	}
+
+	suite, err := ParseSuite([]byte(`
+suite: { name: "Normalize" }
+scenarios:
+  - name: "S"
+    testcases:
+      - name: "C"
+        request: { method: GET, path: / }
+        expect:
+          assertions:
+            - { path: "codes", operator: "equals", value: { 1: "one" } }
+`))
+	if err != nil {
+		t.Fatalf("ParseSuite failed: %v", err)
+	}
+	value := suite.Scenarios[0].TestCases[0].Expect.Assertions[0].Value
+	if m, ok := value.(map[string]any); !ok || m["1"] != "one" {
+		t.Errorf("Expected assertion values to be normalized at load time, got %#v", value)
+	}
+}
+
+func TestTestRunnerSchemaExpectation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id": "not-a-number", "name": "John"}`)
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "user.schema.json"), []byte(`{
+		"type": "object",
+		"required": ["id", "name", "email"],
+		"properties": {"id": {"type": "integer"}, "name": {"type": "string"}}
+	}`), 0644)
+
+	configPath := filepath.Join(tempDir, "schema.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "Schema Suite"
+  base_url: "`+server.URL+`"
+scenarios:
+  - name: "Schema"
+    testcases:
+      - name: "File Schema"
+        request: { method: "GET", path: "/users/1" }
+        expect:
+          status_code: 200
+          schema: "user.schema.json"
+      - name: "Inline Schema"
+        request: { method: "GET", path: "/users/1" }
+        expect:
+          schema:
+            type: object
+            properties:
+              name: { type: string, minLength: 2 }
+`), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("Failed to create TestRunner: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("TestRunner.Run failed: %v", err)
+	}
+
+	results := runner.GetResults()
+	if results[0].Passed {
+		t.Fatal("Expected file schema validation to fail")
+	}
+	for _, want := range []string{"/id: expected integer, got string", "missing required property 'email'"} {
+		if !strings.Contains(results[0].Error, want) {
+			t.Errorf("Expected error to contain %q, got %s", want, results[0].Error)
+		}
+	}
+	if !results[1].Passed {
+		t.Errorf("Expected inline schema validation to pass, got %s", results[1].Error)
+	}
+}
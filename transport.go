@@ -0,0 +1,57 @@
+package apitest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+)
+
+// handlerTransport 在进程内调用 http.Handler 的 RoundTripper，不经过网络
+type handlerTransport struct {
+	handler http.Handler
+}
+
+// RoundTrip 将客户端请求转换为服务端请求交给 handler 处理，返回记录的响应
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	in := req.Clone(req.Context())
+	in.RequestURI = req.URL.RequestURI()
+	in.RemoteAddr = "127.0.0.1:0"
+	if in.Host == "" {
+		in.Host = req.URL.Host
+	}
+	if in.Host == "" {
+		in.Host = "localhost"
+	}
+	if in.Body == nil {
+		in.Body = http.NoBody
+	}
+	if in.Proto == "" {
+		in.Proto, in.ProtoMajor, in.ProtoMinor = "HTTP/1.1", 1, 1
+	}
+
+	// handler 在单独的 goroutine 中运行，与网络传输一样在请求的 context 取消（包括 Client.Timeout）时返回
+	rec := httptest.NewRecorder()
+	done := make(chan any, 1)
+	go func() {
+		defer func() {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			done <- recover()
+		}()
+		t.handler.ServeHTTP(rec, in)
+	}()
+
+	select {
+	case p := <-done:
+		if p != nil {
+			return nil, fmt.Errorf("handler panic: %v", p)
+		}
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
@@ -0,0 +1,106 @@
+package apitest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTestRunnerSetHandler(t *testing.T) {
+	suite, err := ParseSuite([]byte(mockServerSuite))
+	if err != nil {
+		t.Fatalf("ParseSuite failed: %v", err)
+	}
+	mock := NewMockServer(suite)
+	mock.SetOutput(io.Discard)
+
+	configPath := filepath.Join(t.TempDir(), "inprocess.yaml")
+	os.WriteFile(configPath, []byte(`
+suite:
+  name: "In-process Suite"
+  base_url: ""
+scenarios:
+  - name: "Users"
+    testcases:
+      - name: "Get User"
+        request: { method: "GET", path: "/users/1", query: { verbose: "true" } }
+        expect: { status_code: 200, body: { contains: ["John Doe"] } }
+      - name: "Create User"
+        request: { method: "POST", path: "/users", body: { username: "inproc" } }
+        expect:
+          status_code: 201
+          assertions:
+            - { path: "message", operator: "equals", value: "User created" }
+      - name: "Missing User"
+        request: { method: "GET", path: "/users/999" }
+        expect: { status_code: 404 }
+`), 0644)
+
+	runner, err := NewTestRunner(configPath, nil, &MockCleanupHandler{})
+	if err != nil {
+		t.Fatalf("NewTestRunner failed: %v", err)
+	}
+	runner.SetOutput(io.Discard)
+
+	var seen []string
+	runner.SetHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Method+" "+r.RequestURI+" "+r.Host)
+		mock.ServeHTTP(w, r)
+	}))
+
+	if err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, result := range runner.GetResults() {
+		if !result.Passed {
+			t.Errorf("Test %q failed in-process: %s", result.Name, result.Error)
+		}
+	}
+	if len(seen) != 3 || seen[0] != "GET /users/1?verbose=true localhost" {
+		t.Errorf("Expected requests served by the handler, got %v", seen)
+	}
+	if body, _ := runner.GetResults()[1].Response.Body.(map[string]any); fmt.Sprint(body["id"]) != "2" {
+		t.Errorf("Expected captured response body, got %v", runner.GetResults()[1].Response.Body)
+	}
+}
+
+func TestHandlerTransportContextCancel(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	client := &http.Client{
+		Timeout: 50 * time.Millisecond,
+		Transport: handlerTransport{handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		})},
+	}
+
+	start := time.Now()
+	_, err := client.Get("http://api.test/hang")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected hanging handler to be abandoned after the timeout, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://api.test/hang", nil)
+	cancel()
+	if _, err := (handlerTransport{handler: http.NotFoundHandler()}).RoundTrip(req); err != nil && err != context.Canceled {
+		t.Errorf("Expected context.Canceled or a response, got %v", err)
+	}
+
+	_, err = (&http.Client{Transport: handlerTransport{handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})}}).Get("http://api.test/panic")
+	if err == nil || !strings.Contains(err.Error(), "handler panic: boom") {
+		t.Errorf("Expected handler panic to be returned as an error, got %v", err)
+	}
+}
@@ -0,0 +1,460 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// xmlNodeKind XML 节点类型
+type xmlNodeKind int
+
+const (
+	xmlDocument xmlNodeKind = iota
+	xmlElement
+	xmlAttribute
+	xmlText
+)
+
+// xmlNode 解析后的 XML 节点，名称不含命名空间前缀
+type xmlNode struct {
+	kind     xmlNodeKind
+	name     string
+	value    string // 属性值，或元素的直接文本
+	attrs    []*xmlNode
+	children []*xmlNode
+	parent   *xmlNode
+}
+
+// parseXMLDocument 解析 XML 文档
+func parseXMLDocument(data []byte) (*xmlNode, error) {
+	doc := &xmlNode{kind: xmlDocument}
+	cur := doc
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node := &xmlNode{kind: xmlElement, name: t.Name.Local, parent: cur}
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+					continue
+				}
+				node.attrs = append(node.attrs, &xmlNode{kind: xmlAttribute, name: a.Name.Local, value: a.Value, parent: node})
+			}
+			cur.children = append(cur.children, node)
+			cur = node
+		case xml.EndElement:
+			cur = cur.parent
+		case xml.CharData:
+			if cur != doc {
+				cur.value += string(t)
+			}
+		}
+	}
+	if len(doc.children) == 0 {
+		return nil, fmt.Errorf("no root element")
+	}
+	return doc, nil
+}
+
+// text 节点的字符串值：元素为所有子孙文本的拼接（去掉首尾空白），属性和文本节点为其值
+func (n *xmlNode) text() string {
+	if n.kind == xmlAttribute || n.kind == xmlText {
+		return n.value
+	}
+	var b strings.Builder
+	var walk func(*xmlNode)
+	walk = func(node *xmlNode) {
+		b.WriteString(node.value)
+		for _, child := range node.children {
+			walk(child)
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(b.String())
+}
+
+// descendantsOrSelf 返回节点本身及其所有子孙元素
+func (n *xmlNode) descendantsOrSelf() []*xmlNode {
+	nodes := []*xmlNode{n}
+	for _, child := range n.children {
+		nodes = append(nodes, child.descendantsOrSelf()...)
+	}
+	return nodes
+}
+
+// xpath 编译后的 XPath 表达式
+//
+// 支持的语法（XPath 1.0 的常用子集）：
+//   - /a/b 绝对路径、a/b 相对于文档根的路径、//b 任意层级
+//   - * 任意元素、@name 和 @* 属性、text() 直接文本、. 当前节点、.. 父节点
+//   - [1]（从 1 开始）、[last()] 位置谓词，[@id]、[@id='7']、[name!='x']、[text()='a']、[.=1] 条件谓词
+//   - count(path) 返回匹配的节点数，number(path) 将结果转换为数字
+//
+// 元素名忽略命名空间前缀。元素的值为其所有文本（去掉首尾空白）。
+// 匹配一个节点时结果为字符串，匹配多个节点时为 []any
+type xpath struct {
+	expr  string
+	fn    string // count 或 number，为空表示返回节点的值
+	steps []xpathStep
+}
+
+// xpathStep 路径中的一步
+type xpathStep struct {
+	descendant bool   // 由 // 引入
+	axis       string // child、attribute、text、self、parent
+	name       string // * 表示任意名称
+	predicates []xpathPredicate
+}
+
+// xpathPredicate 谓词：位置、存在性或比较
+type xpathPredicate struct {
+	position int // 大于 0 时按位置选择
+	last     bool
+	path     *xpath // 条件左侧的相对路径
+	op       string // = 或 !=，为空表示只判断存在
+	value    string
+}
+
+// compileXPath 编译 XPath 表达式
+func compileXPath(expr string) (*xpath, error) {
+	expr = strings.TrimSpace(expr)
+	x := &xpath{expr: expr}
+	for _, fn := range []string{"count", "number"} {
+		if inner, ok := strings.CutPrefix(expr, fn+"("); ok && strings.HasSuffix(inner, ")") {
+			x.fn = fn
+			expr = strings.TrimSpace(strings.TrimSuffix(inner, ")"))
+			break
+		}
+	}
+	if expr == "" {
+		return nil, fmt.Errorf("invalid xpath '%s': empty path", x.expr)
+	}
+
+	parts, err := splitXPath(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xpath '%s': %w", x.expr, err)
+	}
+	descendant := false
+	for i, part := range parts {
+		if part == "" {
+			// 开头的 / 表示根节点，连续的 // 表示任意层级
+			if i > 0 {
+				descendant = true
+			}
+			continue
+		}
+		step, err := parseXPathStep(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid xpath '%s': %w", x.expr, err)
+		}
+		step.descendant = descendant
+		descendant = false
+		x.steps = append(x.steps, step)
+	}
+	if descendant || len(x.steps) == 0 {
+		return nil, fmt.Errorf("invalid xpath '%s': path must end with a step", x.expr)
+	}
+	return x, nil
+}
+
+// splitXPath 按方括号和引号之外的 / 拆分路径
+func splitXPath(expr string) ([]string, error) {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '\'', '"':
+			end := strings.IndexByte(expr[i+1:], expr[i])
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			i += end + 1
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unexpected ']'")
+			}
+		case '/':
+			if depth == 0 {
+				parts = append(parts, expr[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unterminated '['")
+	}
+	return append(parts, expr[start:]), nil
+}
+
+// parseXPathStep 解析一步：节点测试和谓词
+func parseXPathStep(s string) (xpathStep, error) {
+	var step xpathStep
+	test := s
+	if i := strings.IndexByte(s, '['); i >= 0 {
+		test = s[:i]
+		rest := s[i:]
+		for rest != "" {
+			if rest[0] != '[' {
+				return step, fmt.Errorf("invalid predicate in '%s'", s)
+			}
+			end, err := matchBracket(rest, 0)
+			if err != nil {
+				return step, fmt.Errorf("invalid predicate in '%s'", s)
+			}
+			pred, err := parseXPathPredicate(strings.TrimSpace(rest[1:end]))
+			if err != nil {
+				return step, err
+			}
+			step.predicates = append(step.predicates, pred)
+			rest = rest[end+1:]
+		}
+	}
+
+	test = strings.TrimSpace(test)
+	switch {
+	case test == ".":
+		step.axis = "self"
+	case test == "..":
+		step.axis = "parent"
+	case test == "text()":
+		step.axis = "text"
+	case strings.HasPrefix(test, "@"):
+		step.axis, step.name = "attribute", localName(test[1:])
+	default:
+		step.axis, step.name = "child", localName(test)
+	}
+	if (step.axis == "child" || step.axis == "attribute") && !validXMLName(step.name) {
+		return step, fmt.Errorf("invalid step '%s'", test)
+	}
+	return step, nil
+}
+
+// parseXPathPredicate 解析谓词内容
+func parseXPathPredicate(s string) (xpathPredicate, error) {
+	var pred xpathPredicate
+	if s == "last()" {
+		pred.last = true
+		return pred, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 {
+			return pred, fmt.Errorf("position must start at 1, got %d", n)
+		}
+		pred.position = n
+		return pred, nil
+	}
+
+	left := s
+	if i := xpathOperatorIndex(s); i >= 0 {
+		pred.op = "="
+		left = s[:i]
+		right := s[i+1:]
+		if s[i] == '!' {
+			pred.op = "!="
+			right = s[i+2:]
+		}
+		right = strings.TrimSpace(right)
+		if len(right) >= 2 && (right[0] == '\'' || right[0] == '"') && right[len(right)-1] == right[0] {
+			pred.value = right[1 : len(right)-1]
+		} else if _, err := strconv.ParseFloat(right, 64); err == nil {
+			pred.value = right
+		} else {
+			return pred, fmt.Errorf("predicate value must be a string or number, got '%s'", right)
+		}
+	}
+	path, err := compileXPath(strings.TrimSpace(left))
+	if err != nil {
+		return pred, err
+	}
+	if path.fn != "" {
+		return pred, fmt.Errorf("functions are not supported in predicates")
+	}
+	pred.path = path
+	return pred, nil
+}
+
+// xpathOperatorIndex 返回引号之外第一个 = 或 != 的位置
+func xpathOperatorIndex(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'', '"':
+			if end := strings.IndexByte(s[i+1:], s[i]); end >= 0 {
+				i += end + 1
+			}
+		case '=':
+			return i
+		case '!':
+			if i+1 < len(s) && s[i+1] == '=' {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// localName 去掉命名空间前缀
+func localName(name string) string {
+	if i := strings.LastIndexByte(name, ':'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// validXMLName 是否为合法的元素或属性名（或通配符 *）
+func validXMLName(name string) bool {
+	if name == "*" {
+		return true
+	}
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c > 127 ||
+			i > 0 && (c == '-' || c == '.' || c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// nodes 从 context 开始求值，返回匹配的节点（按文档顺序，不重复）
+func (x *xpath) nodes(context *xmlNode) []*xmlNode {
+	current := []*xmlNode{context}
+	for _, step := range x.steps {
+		var next []*xmlNode
+		seen := make(map[*xmlNode]bool)
+		for _, node := range current {
+			bases := []*xmlNode{node}
+			if step.descendant {
+				bases = node.descendantsOrSelf()
+			}
+			for _, base := range bases {
+				for _, match := range step.apply(base) {
+					if !seen[match] {
+						seen[match] = true
+						next = append(next, match)
+					}
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// apply 对单个节点执行一步，谓词按该节点的候选列表计算位置
+func (step xpathStep) apply(node *xmlNode) []*xmlNode {
+	var candidates []*xmlNode
+	switch step.axis {
+	case "self":
+		candidates = []*xmlNode{node}
+	case "parent":
+		if node.parent != nil {
+			candidates = []*xmlNode{node.parent}
+		}
+	case "text":
+		if node.kind == xmlElement && strings.TrimSpace(node.value) != "" {
+			candidates = []*xmlNode{{kind: xmlText, value: node.value, parent: node}}
+		}
+	case "attribute":
+		for _, attr := range node.attrs {
+			if step.name == "*" || attr.name == step.name {
+				candidates = append(candidates, attr)
+			}
+		}
+	default:
+		for _, child := range node.children {
+			if step.name == "*" || child.name == step.name {
+				candidates = append(candidates, child)
+			}
+		}
+	}
+
+	for _, pred := range step.predicates {
+		var kept []*xmlNode
+		for i, c := range candidates {
+			if pred.matches(c, i+1, len(candidates)) {
+				kept = append(kept, c)
+			}
+		}
+		candidates = kept
+	}
+	return candidates
+}
+
+// matches 判断候选节点是否满足谓词
+func (pred xpathPredicate) matches(node *xmlNode, position, size int) bool {
+	switch {
+	case pred.last:
+		return position == size
+	case pred.position > 0:
+		return position == pred.position
+	}
+
+	matched := pred.path.nodes(node)
+	if pred.op == "" {
+		return len(matched) > 0
+	}
+	// 与 XPath 相同：任意一个节点满足比较即为真
+	for _, m := range matched {
+		if xpathValueEqual(m.text(), pred.value) == (pred.op == "=") {
+			return true
+		}
+	}
+	return false
+}
+
+// xpathValueEqual 比较节点值和谓词中的值，都是数字时按数值比较
+func xpathValueEqual(actual, expected string) bool {
+	a, errA := strconv.ParseFloat(strings.TrimSpace(actual), 64)
+	b, errB := strconv.ParseFloat(expected, 64)
+	if errA == nil && errB == nil {
+		return a == b
+	}
+	return actual == expected
+}
+
+// evaluate 在文档上求值，返回值和是否匹配到节点
+func (x *xpath) evaluate(doc *xmlNode) (any, bool, error) {
+	matched := x.nodes(doc)
+	switch x.fn {
+	case "count":
+		return int64(len(matched)), true, nil
+	case "number":
+		if len(matched) == 0 {
+			return nil, false, nil
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(matched[0].text()), 64)
+		if err != nil {
+			return nil, true, fmt.Errorf("xpath '%s': '%s' is not a number", x.expr, matched[0].text())
+		}
+		return n, true, nil
+	}
+
+	switch len(matched) {
+	case 0:
+		return nil, false, nil
+	case 1:
+		return matched[0].text(), true, nil
+	}
+	values := make([]any, len(matched))
+	for i, m := range matched {
+		values[i] = m.text()
+	}
+	return values, true, nil
+}
@@ -0,0 +1,78 @@
+package apitest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestXPath(t *testing.T) {
+	doc, err := parseXMLDocument([]byte(`<?xml version="1.0"?>
+<ns:orders xmlns:ns="urn:orders" count="3">
+  <order id="1" status="paid">
+    <customer>Alice</customer>
+    <total>10.5</total>
+    <item sku="A">2</item>
+  </order>
+  <order id="2" status="open">
+    <customer>Bob</customer>
+    <total>25</total>
+    <item sku="A">1</item>
+    <item sku="B">4</item>
+  </order>
+  <ns:order id="3" status="paid">
+    <customer>  Carol  </customer>
+    <total>40</total>
+  </ns:order>
+</ns:orders>`))
+	if err != nil {
+		t.Fatalf("parseXMLDocument failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want any
+	}{
+		{"/orders/@count", "3"},
+		{"orders/order[1]/customer", "Alice"},
+		{"/orders/order[last()]/@id", "3"},
+		{"/orders/order[@status='paid']/@id", []any{"1", "3"}},
+		{"/orders/order[customer='Bob']/total", "25"},
+		{"/orders/order[total=25]/@id", "2"},
+		{"/orders/order[@status!='paid']/customer/text()", "Bob"},
+		{"/orders/order[item][2]/@id", "2"},
+		{"//item/@sku", []any{"A", "A", "B"}},
+		{"//order[2]/customer", "Bob"},
+		{"//item[1]", []any{"2", "1"}},
+		{"//customer[.='Carol']/../@id", "3"},
+		{"/orders/*/total", []any{"10.5", "25", "40"}},
+		{"/orders/order[3]/@*", []any{"3", "paid"}},
+		{"count(//item)", int64(3)},
+		{"count(//missing)", int64(0)},
+		{"number(/orders/order[1]/total)", 10.5},
+		{"/orders/missing", nil},
+	}
+	for _, tt := range tests {
+		x, err := compileXPath(tt.path)
+		if err != nil {
+			t.Errorf("compileXPath(%q) failed: %v", tt.path, err)
+			continue
+		}
+		got, _, err := x.evaluate(doc)
+		if err != nil {
+			t.Errorf("evaluate(%q) returned error: %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("evaluate(%q) = %#v, want %#v", tt.path, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"", "/", "/orders//", "/orders/order[", "/orders/order[0]", "/orders/<bad>", "/orders/order[@id=x]"} {
+		if _, err := compileXPath(invalid); err == nil {
+			t.Errorf("compileXPath(%q) should fail", invalid)
+		}
+	}
+	if _, err := parseXMLDocument([]byte("not xml")); err == nil {
+		t.Error("parseXMLDocument should fail on text")
+	}
+}